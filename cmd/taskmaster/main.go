@@ -2,12 +2,18 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"taskmaster/internal/cgroup"
 	"taskmaster/internal/config"
+	"taskmaster/internal/control"
+	"taskmaster/internal/logfile"
 	"taskmaster/internal/logger"
 	"taskmaster/internal/process"
 	"taskmaster/internal/shell"
@@ -17,14 +23,90 @@ import (
 func main() {
 	var configFile = flag.String("config", "configs/example.yml", "Path to configuration file")
 	var webPort = flag.Int("web-port", 0, "Web server port (0 = disabled)")
+	var webBind = flag.String("web-bind", web.DefaultBindAddress, "Web server bind address (0.0.0.0 = all interfaces)")
+	var webTLSCert = flag.String("web-tls-cert", "", "TLS certificate file; enables HTTPS with -web-tls-key")
+	var webTLSKey = flag.String("web-tls-key", "", "TLS private key file")
+	var webUser = flag.String("web-user", "", "Require HTTP basic auth with this user (password from -web-password-file)")
+	var webPasswordFile = flag.String("web-password-file", "", "File containing the basic auth password")
+	var webTokenFile = flag.String("web-token-file", "", "File containing a bearer token accepted by the web server")
+	var webOrigins = flag.String("web-allowed-origins", "", "Comma-separated extra origins allowed for the WebSocket and API calls")
+	var socketPath = flag.String("socket", control.DefaultSocketPath(), "Control socket path (empty = disabled)")
+	var daemonMode = new(bool)
+	flag.BoolVar(daemonMode, "daemon", false, "Run headless; only SIGTERM/SIGINT stop the supervisor")
+	flag.BoolVar(daemonMode, "foreground", false, "Alias for -daemon (for systemd and containers)")
+	var withShell = flag.Bool("shell", false, "Also attach the interactive shell in daemon mode")
+	var shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "Deadline for stopping all programs before SIGKILL")
+	var checkConfig = flag.Bool("check-config", false, "Validate the configuration file and exit")
+	var logFormat = flag.String("log-format", "text", "Supervisor log format: text or json (one object per line)")
+	var logLevel = flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	var logFile = flag.String("log-file", "taskmaster.log", "Supervisor log file path")
+	var logMaxBytes = flag.String("log-maxbytes", "50MB", "Rotate the supervisor log past this size (0 = never)")
+	var logInterval = flag.Duration("log-rotate-interval", 0, "Rotate the supervisor log after this long, e.g. 24h (0 = never)")
+	var logBackups = flag.Int("log-backups", config.DefaultLogfileBackups, "Number of rotated supervisor logs to keep")
+	var logCompress = flag.Bool("log-compress", false, "Gzip rotated supervisor logs")
+	var syslogTarget = flag.String("syslog", "", "Also send logs to syslog: unix:///dev/log or udp://host:port (empty = disabled)")
+	var syslogFacility = flag.String("syslog-facility", "daemon", "Syslog facility (daemon, user, local0 ... local7)")
+	var syslogOutput = flag.Bool("syslog-output", false, "Forward program stdout/stderr to syslog, tagged with the program name")
+	var sampleInterval = flag.Duration("sample-interval", process.DefaultSampleInterval, "Interval between /proc samples of CPU, memory and I/O (0 = disabled)")
+	var useCgroups = flag.Bool("cgroups", true, "Give programs their own cgroup v2 when some program sets memory_max, cpu_max or pids_max and the hierarchy is writable")
+	var journal = flag.Bool("journal", false, "Write console logs with journald priority prefixes instead of plain lines")
 	flag.Parse()
 
+	if *checkConfig {
+		os.Exit(runConfigCheck(*configFile))
+	}
+
 	// Initialize logger
-	appLogger, err := logger.New("taskmaster.log")
+	minLevel, err := logger.ParseLevel(*logLevel)
+	if err != nil {
+		log.Fatalf("Invalid -log-level: %v", err)
+	}
+	format, err := logger.ParseFormat(*logFormat)
+	if err != nil {
+		log.Fatalf("Invalid -log-format: %v", err)
+	}
+
+	maxBytes, err := config.ParseByteSize(*logMaxBytes)
+	if err != nil {
+		log.Fatalf("Invalid -log-maxbytes: %v", err)
+	}
+	if *logInterval < 0 || *logBackups < 0 {
+		log.Fatalf("-log-rotate-interval and -log-backups cannot be negative")
+	}
+	if *syslogOutput && *syslogTarget == "" {
+		log.Fatalf("-syslog-output requires -syslog")
+	}
+
+	appLogger, err := logger.New(*logFile, logfile.Options{
+		MaxBytes: int64(maxBytes),
+		Interval: *logInterval,
+		Backups:  *logBackups,
+		Compress: *logCompress,
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	defer appLogger.Close()
+	appLogger.SetLevel(minLevel)
+	appLogger.SetFormat(format)
+
+	if *journal {
+		appLogger.SetConsole(false)
+		appLogger.AddSink(logger.NewJournalSink(os.Stdout))
+	}
+
+	var syslogSink *logger.SyslogSink
+	if *syslogTarget != "" {
+		facility, err := logger.ParseFacility(*syslogFacility)
+		if err != nil {
+			log.Fatalf("Invalid -syslog-facility: %v", err)
+		}
+		syslogSink, err = logger.NewSyslogSink(*syslogTarget, facility, "taskmaster")
+		if err != nil {
+			log.Fatalf("Failed to initialize syslog: %v", err)
+		}
+		appLogger.AddSink(syslogSink)
+	}
 
 	appLogger.Info("🚀 Starting Taskmaster...")
 
@@ -38,12 +120,24 @@ func main() {
 
 	// Initialize process manager
 	processManager := process.NewManager(cfg, appLogger)
+	setupCgroups(processManager, cfg, *useCgroups, appLogger)
+	if *syslogOutput {
+		processManager.AddOutputBroadcaster(process.NewSyslogOutput(syslogSink))
+	}
 
 	// Initialize web server only if port is specified
 	if *webPort > 0 {
+		webOptions, err := loadWebOptions(*webBind, *webTLSCert, *webTLSKey, *webUser, *webPasswordFile, *webTokenFile, *webOrigins)
+		if err != nil {
+			appLogger.Fatal("Invalid web server options: %v", err)
+		}
+
 		webServer := web.NewServer(*webPort, processManager, appLogger)
+		webServer.SetConfigFile(*configFile)
+		webServer.SetOptions(webOptions)
 		appLogger.SetBroadcaster(webServer.GetHub())
 		processManager.SetStatusBroadcaster(webServer.GetHub())
+		processManager.AddOutputBroadcaster(webServer.GetHub())
 
 		// Start web server in background
 		go func() {
@@ -54,6 +148,19 @@ func main() {
 		}()
 	}
 
+	// Initialize control socket only if a path is specified
+	if *socketPath != "" {
+		controlServer := control.NewServer(*socketPath, processManager, appLogger)
+		controlServer.SetConfigFile(*configFile)
+		defer controlServer.Close()
+
+		go func() {
+			if err := controlServer.Start(); err != nil {
+				appLogger.Error("Control socket failed: %v", err)
+			}
+		}()
+	}
+
 	// Start processes marked as autostart
 	if err := processManager.StartAutoStartProcesses(); err != nil {
 		appLogger.Error("Failed to start some processes: %v", err)
@@ -61,48 +168,204 @@ func main() {
 
 	// Start periodic status checking
 	processManager.StartPeriodicStatusCheck()
+	processManager.StartResourceSampler(*sampleInterval)
+
+	// Handle SIGHUP for config reload, SIGUSR1 to reopen logs and SIGINT/SIGTERM for shutdown
+	shutdownChan := make(chan os.Signal, 1)
+	go handleSignals(processManager, appLogger, *configFile, shutdownChan)
+
+	if *daemonMode {
+		appLogger.Info("👻 Running in daemon mode, send SIGTERM or SIGINT to stop")
+	}
 
-	// Handle SIGHUP for config reload
-	go handleSignals(processManager, appLogger, *configFile)
+	// The shell is always attached in interactive mode and optional in daemon mode
+	var shellInstance *shell.Shell
+	shellDone := make(chan struct{})
+	if !*daemonMode || *withShell {
+		shellInstance = shell.New(processManager, appLogger)
+		shellInstance.SetConfigFile(*configFile) // Pasar el archivo de configuración
 
-	// Start interactive shell
-	shellInstance := shell.New(processManager, appLogger)
-	shellInstance.SetConfigFile(*configFile) // Pasar el archivo de configuración
+		appLogger.Info("🎮 Starting interactive shell...")
+		go func() {
+			shellInstance.Run()
+			close(shellDone)
+		}()
+	}
 
-	appLogger.Info("🎮 Starting interactive shell...")
-	shellInstance.Run()
+	waitForShutdown(shutdownChan, shellDone, *daemonMode, appLogger)
 
 	// Cleanup al salir
 	appLogger.Info("🛑 Shutting down Taskmaster...")
+	if shellInstance != nil {
+		shellInstance.Close()
+	}
+
+	stopAllPrograms(processManager, appLogger, *shutdownTimeout)
+
+	appLogger.Info("👋 Taskmaster shutdown complete")
+}
+
+// waitForShutdown blocks until a shutdown signal arrives or, in interactive
+// mode, until the shell exits. In daemon mode closing the shell is not enough.
+func waitForShutdown(shutdownChan <-chan os.Signal, shellDone <-chan struct{}, daemonMode bool, logger *logger.Logger) {
+	for {
+		select {
+		case sig := <-shutdownChan:
+			logger.Info("📡 Received %v, stopping all processes...", sig)
+			return
+		case <-shellDone:
+			if !daemonMode {
+				return
+			}
+			logger.Info("🎮 Shell closed, supervisor keeps running in daemon mode")
+			shellDone = nil
+		}
+	}
+}
+
+// stopAllPrograms detiene todos los programas gestionados y registra un resumen
+func stopAllPrograms(pm *process.Manager, logger *logger.Logger, timeout time.Duration) {
+	report := pm.Shutdown(timeout)
+
+	logger.Info("📋 Shutdown finished in %s: %d exited cleanly, %d killed",
+		report.Duration.Round(time.Millisecond), len(report.Clean), len(report.Killed))
+	if len(report.Clean) > 0 {
+		logger.Info("Exited cleanly: %s", strings.Join(report.Clean, ", "))
+	}
+	if len(report.Killed) > 0 {
+		logger.Error("Killed after timeout: %s", strings.Join(report.Killed, ", "))
+	}
+}
+
+// setupCgroups activa los cgroups v2 sólo si algún programa fija memory_max,
+// cpu_max o pids_max: Setup mueve a taskmaster a otro cgroup y delega
+// controladores, y eso no debe pasar en un sistema que no lo ha pedido
+func setupCgroups(pm *process.Manager, cfg *config.Config, enabled bool, logger *logger.Logger) {
+	limited := cfg.CgroupLimited()
+	if len(limited) == 0 {
+		return
+	}
+	if !enabled {
+		logger.Warn("⚠️  cgroups are disabled (-cgroups=false), limits of %s are not applied", strings.Join(limited, ", "))
+		return
+	}
+
+	hierarchy, err := cgroup.Setup()
+	if err != nil {
+		logger.Warn("⚠️  cgroup v2 is not available (%v), limits of %s are not applied", err, strings.Join(limited, ", "))
+		return
+	}
+
+	controllers := "none"
+	if active := hierarchy.Controllers(); len(active) > 0 {
+		controllers = strings.Join(active, ", ")
+	}
+	logger.Info("🧩 Programs run in their own cgroups under %s (controllers: %s)", hierarchy.Path(), controllers)
+	pm.SetCgroups(hierarchy)
+}
+
+// loadWebOptions construye las opciones del servidor web, leyendo los secretos
+// de ficheros para que no aparezcan en la línea de comandos
+func loadWebOptions(bind, certFile, keyFile, user, passwordFile, tokenFile, origins string) (web.Options, error) {
+	options := web.Options{
+		BindAddress: bind,
+		TLSCertFile: certFile,
+		TLSKeyFile:  keyFile,
+		Username:    user,
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return options, fmt.Errorf("-web-tls-cert and -web-tls-key must be used together")
+	}
 
-	// Detener todos los procesos
-	status := processManager.GetStatus()
-	for programName := range status {
-		if err := processManager.StopProgram(programName); err != nil {
-			appLogger.Error("Error stopping program %s during shutdown: %v", programName, err)
+	if user != "" {
+		if passwordFile == "" {
+			return options, fmt.Errorf("-web-user requires -web-password-file")
+		}
+		password, err := readSecret(passwordFile)
+		if err != nil {
+			return options, err
 		}
+		options.Password = password
+	} else if passwordFile != "" {
+		return options, fmt.Errorf("-web-password-file requires -web-user")
 	}
 
-	appLogger.Info("👋 Taskmaster shutdown complete")
+	if tokenFile != "" {
+		token, err := readSecret(tokenFile)
+		if err != nil {
+			return options, err
+		}
+		options.Token = token
+	}
+
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			options.AllowedOrigins = append(options.AllowedOrigins, origin)
+		}
+	}
+	return options, nil
+}
+
+// readSecret lee un secreto de un fichero, sin espacios ni saltos de línea finales
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
+
+// runConfigCheck valida el fichero de configuración sin arrancar nada y
+// devuelve el código de salida: 0 si es válido, 1 si no
+func runConfigCheck(configFile string) int {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Configuration %s is valid (%d programs)\n", configFile, len(cfg.Programs))
+	return 0
 }
 
-func handleSignals(pm *process.Manager, logger *logger.Logger, configFile string) {
+func handleSignals(pm *process.Manager, logger *logger.Logger, configFile string, shutdownChan chan<- os.Signal) {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM)
 
 	for sig := range sigChan {
 		switch sig {
 		case syscall.SIGHUP:
 			logger.Info("📡 Received SIGHUP, reloading configuration...")
-			if err := pm.ReloadConfig(configFile); err != nil {
+			report, err := pm.ReloadConfig(configFile)
+			switch {
+			case err != nil:
 				logger.Error("Failed to reload config: %v", err)
-			} else {
+			case report.RolledBack:
+				logger.Error("❌ Reload via SIGHUP failed, previous configuration restored: %s", report.RollbackReason)
+			case report.Failed():
+				logger.Error("⚠️  Configuration reloaded via SIGHUP with errors")
+			default:
 				logger.Info("✅ Configuration reloaded via SIGHUP")
 			}
+		case syscall.SIGUSR1:
+			// Tras mover los ficheros (p. ej. logrotate) se reabren sus rutas
+			if err := logger.Reopen(); err != nil {
+				logger.Error("Failed to reopen log file: %v", err)
+			}
+			if err := pm.ReopenLogs(); err != nil {
+				logger.Error("Failed to reopen program logs: %v", err)
+			}
+			logger.Info("📡 Received SIGUSR1, log files reopened")
 		case syscall.SIGINT, syscall.SIGTERM:
-			logger.Info("📡 Received shutdown signal, stopping all processes...")
-			// El cleanup se hará en main() cuando termine el shell
-			os.Exit(0)
+			// El cleanup se hace en main(), que espera en shutdownChan
+			select {
+			case shutdownChan <- sig:
+			default:
+			}
 		}
 	}
 }
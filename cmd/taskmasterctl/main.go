@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"taskmaster/internal/config"
+	"taskmaster/internal/control"
+	"taskmaster/internal/process"
+)
+
+func main() {
+	var socketPath = flag.String("socket", control.DefaultSocketPath(), "Path to the taskmaster control socket")
+	var jsonOutput = flag.Bool("json", false, "Print the raw JSON response")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(control.ExitUsage)
+	}
+
+	if *socketPath == "" {
+		fmt.Fprintf(os.Stderr, "taskmasterctl: no control socket: use -socket or set XDG_RUNTIME_DIR\n")
+		os.Exit(control.ExitUsage)
+	}
+
+	req, err := buildRequest(args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskmasterctl: %v\n", err)
+		usage()
+		os.Exit(control.ExitUsage)
+	}
+
+	if tailReq, err := process.ParseTailArgs(req.Args); req.Command == "tail" && err == nil && tailReq.Follow {
+		os.Exit(followTail(*socketPath, req, *jsonOutput))
+	}
+
+	resp, err := control.Call(*socketPath, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskmasterctl: %v\n", err)
+		os.Exit(control.ExitUnavailable)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(resp)
+	} else {
+		printResponse(args[0], resp)
+	}
+
+	os.Exit(resp.ExitCode())
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: taskmasterctl [-socket path] [-json] <command> [args]
+
+Commands:
+  status [program...]    Show status of all or the given programs
+  top [program...]       Show CPU, memory, threads, FDs and I/O of each instance
+  start <target...>      Start programs or single instances (prog:N or prog_N)
+  stop <target...>       Stop programs or single instances
+  restart <target...>    Restart programs or single instances
+  signal <SIG> <target...>
+                         Send a signal to programs or single instances
+  scale <program> <n>    Run n instances, starting or stopping only the difference
+  reload [--dry-run]     Reload the configuration file, or only show the plan
+  tail <target> [stdout|stderr] [-n N] [-f]
+                         Show recent output of a program or instance, -f follows it
+  clear [program...]     Clean process history
+
+Exit codes:
+  %d  success
+  %d  at least one program failed
+  %d  usage error
+  %d  daemon unreachable or protocol error
+`, control.ExitOK, control.ExitFailure, control.ExitUsage, control.ExitUnavailable)
+}
+
+func buildRequest(command string, args []string) (control.Request, error) {
+	req := control.Request{Command: command, Args: args}
+
+	switch command {
+	case "status", "clear":
+	case "top":
+		// top is a view of the status response with the resource usage
+		req.Command = "status"
+	case "start", "stop", "restart":
+		if len(args) == 0 {
+			return req, fmt.Errorf("%s requires at least one program name", command)
+		}
+	case "scale":
+		if len(args) != 2 {
+			return req, fmt.Errorf("scale requires a program name and a number of processes")
+		}
+	case "signal":
+		if len(args) < 2 {
+			return req, fmt.Errorf("signal requires a signal name and at least one target")
+		}
+	case "tail":
+		if _, err := process.ParseTailArgs(args); err != nil {
+			return req, err
+		}
+	case "reload":
+		if len(args) > 1 || (len(args) == 1 && args[0] != control.DryRunFlag) {
+			return req, fmt.Errorf("reload only accepts %s", control.DryRunFlag)
+		}
+	default:
+		return req, fmt.Errorf("unknown command: %s", command)
+	}
+
+	return req, nil
+}
+
+func printResponse(command string, resp *control.Response) {
+	switch command {
+	case "status":
+		printStatus(resp.Status)
+	case "top":
+		printTop(resp.Status)
+	}
+
+	for _, line := range resp.Output {
+		printOutputLine(line)
+	}
+
+	if resp.Reload != nil {
+		for _, line := range resp.Reload.Lines() {
+			fmt.Println(line)
+		}
+	}
+
+	for _, result := range resp.Results {
+		if result.OK {
+			fmt.Printf("%s: %s ok\n", result.Target, command)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: ERROR %s\n", result.Target, result.Error)
+		}
+	}
+
+	if resp.Error != "" {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", resp.Error)
+	} else if len(resp.Results) == 0 && command != "status" && command != "top" && command != "tail" {
+		fmt.Printf("%s ok\n", command)
+	}
+}
+
+func printStatus(status []process.InstanceInfo) {
+	if len(status) == 0 {
+		fmt.Println("No running programs")
+		return
+	}
+
+	fmt.Printf("%-20s %-12s %-8s %-10s %-8s\n", "NAME", "STATE", "PID", "UPTIME", "RESTARTS")
+	fmt.Println(strings.Repeat("-", 70))
+
+	for _, info := range status {
+		pidStr := "-"
+		if info.PID > 0 {
+			pidStr = fmt.Sprintf("%d", info.PID)
+		}
+
+		uptime := "N/A"
+		if info.State == process.StateRunning {
+			uptime = fmt.Sprintf("%.0fs", info.Uptime)
+		}
+
+		fmt.Printf("%-20s %-12s %-8s %-10s %-8d\n",
+			info.Name,
+			info.State.String(),
+			pidStr,
+			uptime,
+			info.RestartCount)
+	}
+}
+
+func printTop(status []process.InstanceInfo) {
+	if len(status) == 0 {
+		fmt.Println("No running programs")
+		return
+	}
+
+	fmt.Printf("%-20s %-12s %-8s %-6s %-7s %-9s %-8s %-6s %-9s %-9s\n",
+		"NAME", "STATE", "PID", "PROCS", "CPU%", "RSS", "THREADS", "FDS", "READ", "WRITE")
+	fmt.Println(strings.Repeat("-", 102))
+
+	for _, info := range status {
+		if info.Usage == nil {
+			fmt.Printf("%-20s %-12s %-8s %-6s %-7s %-9s %-8s %-6s %-9s %-9s\n",
+				info.Name, info.State.String(), "-", "-", "-", "-", "-", "-", "-", "-")
+			continue
+		}
+
+		usage := info.Usage
+		fmt.Printf("%-20s %-12s %-8d %-6d %-7.1f %-9s %-8d %-6d %-9s %-9s\n",
+			info.Name,
+			info.State.String(),
+			info.PID,
+			usage.Processes,
+			usage.CPUPercent,
+			config.ByteSize(usage.RSS),
+			usage.Threads,
+			usage.OpenFDs,
+			config.ByteSize(usage.ReadBytes),
+			config.ByteSize(usage.WriteBytes))
+	}
+}
+
+// followTail prints streamed output until the daemon goes away or the user
+// interrupts taskmasterctl.
+func followTail(socketPath string, req control.Request, jsonOutput bool) int {
+	exitCode := control.ExitOK
+	err := control.Follow(socketPath, req, func(resp *control.Response) bool {
+		if resp.Error != "" {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", resp.Error)
+			exitCode = resp.ExitCode()
+			return false
+		}
+		for _, line := range resp.Output {
+			if jsonOutput {
+				json.NewEncoder(os.Stdout).Encode(line)
+			} else {
+				printOutputLine(line)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "taskmasterctl: %v\n", err)
+		return control.ExitUnavailable
+	}
+	return exitCode
+}
+
+func printOutputLine(line process.OutputLine) {
+	if line.Stream == process.StreamStderr {
+		fmt.Fprintf(os.Stderr, "[%s] %s\n", line.Instance, line.Text)
+		return
+	}
+	fmt.Printf("[%s] %s\n", line.Instance, line.Text)
+}
@@ -0,0 +1,321 @@
+// Package cgroup coloca los programas supervisados en su propio subárbol de
+// cgroup v2, les aplica límites de memoria, CPU y pids y mata instancias enteras
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cpuPeriod es el periodo de cpu.max, en microsegundos
+const cpuPeriod = 100000
+
+// controllers son los controladores que taskmaster activa para sus programas
+var controllers = []string{"cpu", "memory", "pids"}
+
+// Limits son los límites de recursos del cgroup de un programa; 0 es sin límite
+type Limits struct {
+	MemoryMax int64   // bytes
+	CPUMax    float64 // CPUs
+	PidsMax   int
+}
+
+// Hierarchy es el subárbol delegado a taskmaster, bajo su cgroup propio:
+//
+//	<propio>/supervisor                    el propio taskmaster
+//	<propio>/programs/<programa>           límites de cada programa
+//	<propio>/programs/<programa>/<run>     una ejecución de una instancia
+type Hierarchy struct {
+	root        string // cgroup propio de taskmaster
+	programs    string
+	controllers map[string]bool // controladores activados para los programas
+}
+
+// Setup localiza la jerarquía cgroup v2, mueve taskmaster a una hoja de su
+// propio cgroup para poder activar controladores por debajo y crea el subárbol
+// de programas. Falla si no hay una jerarquía cgroup v2 con permiso de escritura
+func Setup() (*Hierarchy, error) {
+	mount, err := mountPoint()
+	if err != nil {
+		return nil, err
+	}
+	self, err := selfPath()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hierarchy{
+		root:        filepath.Join(mount, self),
+		controllers: make(map[string]bool),
+	}
+	h.programs = filepath.Join(h.root, "programs")
+
+	// Un cgroup que no es la raíz y tiene procesos no puede activar
+	// controladores para sus hijos (regla "no internal processes")
+	if self != "/" {
+		supervisor := filepath.Join(h.root, "supervisor")
+		if err := mkdir(supervisor); err != nil {
+			return nil, err
+		}
+		if err := addProcess(supervisor, os.Getpid()); err != nil {
+			return nil, err
+		}
+	}
+
+	available, err := readControllers(filepath.Join(h.root, "cgroup.controllers"))
+	if err != nil {
+		return nil, err
+	}
+	if err := mkdir(h.programs); err != nil {
+		return nil, err
+	}
+	for _, controller := range controllers {
+		if !available[controller] {
+			continue
+		}
+		// Los dos niveles deben delegar un controlador para que lo reciban los programas
+		if enable(h.root, controller) == nil && enable(h.programs, controller) == nil {
+			h.controllers[controller] = true
+		}
+	}
+	return h, nil
+}
+
+// Path devuelve el cgroup propio de taskmaster
+func (h *Hierarchy) Path() string {
+	return h.root
+}
+
+// Controllers devuelve los controladores disponibles para los cgroups de programa
+func (h *Hierarchy) Controllers() []string {
+	var enabled []string
+	for _, controller := range controllers {
+		if h.controllers[controller] {
+			enabled = append(enabled, controller)
+		}
+	}
+	return enabled
+}
+
+// ConfigureProgram crea el cgroup de un programa y escribe sus límites; los que
+// no tienen su controlador disponible se informan en el error tras aplicar el
+// resto
+func (h *Hierarchy) ConfigureProgram(program string, limits Limits) error {
+	dir := filepath.Join(h.programs, program)
+	if err := mkdir(dir); err != nil {
+		return err
+	}
+
+	var missing []string
+	write := func(controller, file, value string, limited bool) error {
+		if !h.controllers[controller] {
+			if limited {
+				missing = append(missing, file)
+			}
+			return nil
+		}
+		return writeFile(filepath.Join(dir, file), value)
+	}
+
+	memory := "max"
+	if limits.MemoryMax > 0 {
+		memory = strconv.FormatInt(limits.MemoryMax, 10)
+	}
+	cpu := fmt.Sprintf("max %d", cpuPeriod)
+	if limits.CPUMax > 0 {
+		cpu = fmt.Sprintf("%d %d", int64(limits.CPUMax*cpuPeriod), cpuPeriod)
+	}
+	pids := "max"
+	if limits.PidsMax > 0 {
+		pids = strconv.Itoa(limits.PidsMax)
+	}
+
+	if err := write("memory", "memory.max", memory, limits.MemoryMax > 0); err != nil {
+		return err
+	}
+	if err := write("cpu", "cpu.max", cpu, limits.CPUMax > 0); err != nil {
+		return err
+	}
+	if err := write("pids", "pids.max", pids, limits.PidsMax > 0); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("controllers for %s are not available in %s", strings.Join(missing, ", "), h.root)
+	}
+	return nil
+}
+
+// RemoveProgram elimina el cgroup de un programa cuando ya no quedan instancias
+func (h *Hierarchy) RemoveProgram(program string) error {
+	return removeDir(filepath.Join(h.programs, program))
+}
+
+// Remove elimina el subárbol de programas cuando no queda ningún cgroup de programa
+func (h *Hierarchy) Remove() error {
+	return removeDir(h.programs)
+}
+
+// Instance crea la hoja de una ejecución de una instancia de un programa; cada
+// ejecución tiene la suya para que limpiar tras una nunca afecte a la siguiente
+func (h *Hierarchy) Instance(program, run string) (*Group, error) {
+	dir := filepath.Join(h.programs, program, run)
+	if err := mkdir(dir); err != nil {
+		return nil, err
+	}
+	return &Group{path: dir}, nil
+}
+
+// Group es la hoja de una ejecución: el proceso y todos sus descendientes
+type Group struct {
+	path string
+}
+
+// Path devuelve el directorio del cgroup
+func (g *Group) Path() string {
+	return g.path
+}
+
+// Add mueve un proceso al grupo; sus hijos nacen en él
+func (g *Group) Add(pid int) error {
+	return addProcess(g.path, pid)
+}
+
+// Kill envía SIGKILL a todos los procesos del grupo, incluidos los
+// descendientes que dejaron el grupo de procesos o que adoptó init
+func (g *Group) Kill() error {
+	// cgroup.kill existe desde Linux 5.14
+	err := writeFile(filepath.Join(g.path, "cgroup.kill"), "1")
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	pids, err := g.Processes()
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			return err
+		}
+	}
+	return nil
+}
+
+// Processes devuelve los PIDs del grupo
+func (g *Group) Processes() ([]int, error) {
+	file, err := os.Open(filepath.Join(g.path, "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pids []int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if pid, err := strconv.Atoi(strings.TrimSpace(scanner.Text())); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, scanner.Err()
+}
+
+// Remove borra el grupo; falla mientras queden procesos en él
+func (g *Group) Remove() error {
+	return removeDir(g.path)
+}
+
+// mountPoint localiza dónde está montada la jerarquía cgroup v2, que en
+// sistemas híbridos no es /sys/fs/cgroup
+func mountPoint() (string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// 42 32 0:38 / /sys/fs/cgroup/unified rw,relatime - cgroup2 cgroup2 rw
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) && fields[i+1] == "cgroup2" && len(fields) > 4 {
+				return fields[4], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no cgroup v2 hierarchy is mounted")
+}
+
+// selfPath devuelve el cgroup de taskmaster relativo al punto de montaje v2
+func selfPath() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, found := strings.CutPrefix(line, "0::"); found {
+			return path, nil
+		}
+	}
+	return "", errors.New("taskmaster is not in a cgroup v2 hierarchy")
+}
+
+func readControllers(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]bool)
+	for _, controller := range strings.Fields(string(data)) {
+		available[controller] = true
+	}
+	return available, nil
+}
+
+func enable(dir, controller string) error {
+	return writeFile(filepath.Join(dir, "cgroup.subtree_control"), "+"+controller)
+}
+
+func addProcess(dir string, pid int) error {
+	return writeFile(filepath.Join(dir, "cgroup.procs"), strconv.Itoa(pid))
+}
+
+func mkdir(dir string) error {
+	if err := os.Mkdir(dir, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+func removeDir(dir string) error {
+	if err := syscall.Rmdir(dir); err != nil && err != syscall.ENOENT {
+		return &os.PathError{Op: "rmdir", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// writeFile escribe un fichero de control, que no debe crearse ni truncarse
+func writeFile(path, value string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s to %s: %w", value, path, err)
+	}
+	return nil
+}
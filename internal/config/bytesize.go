@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize es un tamaño en bytes que en el YAML admite un entero o un valor
+// con sufijo: 512KB, 50MB, 1GB
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// UnmarshalYAML implementa yaml.Unmarshaler
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// ParseByteSize convierte "50MB", "512KB" o "1024" a bytes
+func ParseByteSize(text string) (ByteSize, error) {
+	upper := strings.ToUpper(strings.TrimSpace(text))
+
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use bytes or a KB/MB/GB suffix)", text)
+	}
+	return ByteSize(n * multiplier), nil
+}
+
+// String muestra el tamaño con la mayor unidad que no lo deja por debajo de 1:
+// 512B, 1.5KB, 20.0MB
+func (b ByteSize) String() string {
+	for _, unit := range byteSizeUnits {
+		if unit.size > 1 && int64(b) >= unit.size {
+			return fmt.Sprintf("%.1f%s", float64(b)/float64(unit.size), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
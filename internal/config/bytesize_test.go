@@ -0,0 +1,75 @@
+package config
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1024", 1024, false},
+		{"512B", 512, false},
+		{"512KB", 512 << 10, false},
+		{"50MB", 50 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"50mb", 50 << 20, false},
+		{" 10 MB ", 10 << 20, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-1", 0, true},
+		{"1.5MB", 0, true},
+		{"10TB", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseByteSize(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		size ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{1536, "1.5KB"},
+		{20 << 20, "20.0MB"},
+		{3 << 30, "3.0GB"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.size.String(); got != tt.want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(tt.size), got, tt.want)
+		}
+	}
+}
+
+func TestLoadByteSize(t *testing.T) {
+	cfg := loadYAML(t, `
+programs:
+  web:
+    cmd: "sleep 1"
+    stdout_logfile_maxbytes: 50MB
+    max_rss: 1048576
+`)
+	program := cfg.Programs["web"]
+	if program.StdoutLogfileMaxBytes != 50<<20 {
+		t.Errorf("stdout_logfile_maxbytes = %d, want %d", program.StdoutLogfileMaxBytes, 50<<20)
+	}
+	if program.MaxRSS != 1<<20 {
+		t.Errorf("max_rss = %d, want %d", program.MaxRSS, 1<<20)
+	}
+}
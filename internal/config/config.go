@@ -1,42 +1,112 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultPriority es la prioridad de los programas que no la especifican
+const DefaultPriority = 999
+
+// DefaultLogfileBackups es el número de ficheros rotados que se conservan por defecto
+const DefaultLogfileBackups = 10
+
 type Config struct {
 	Programs map[string]Program `yaml:"programs"`
 }
 
+// Las etiquetas reload indican cómo se aplica un cambio del campo en una
+// recarga: "restart" exige reiniciar el proceso, "scale" ajusta el número de
+// instancias y los campos sin etiqueta se aplican en caliente.
 type Program struct {
-	Cmd          string            `yaml:"cmd"`
-	NumProcs     int               `yaml:"numprocs"`
+	Cmd          string            `yaml:"cmd" reload:"restart"`
+	NumProcs     int               `yaml:"numprocs" reload:"scale"`
 	AutoStart    bool              `yaml:"autostart"`
 	AutoRestart  string            `yaml:"autorestart"` // always, never, unexpected
 	ExitCodes    []int             `yaml:"exitcodes"`
-	StartTime    int               `yaml:"starttime"`    // seconds to consider "successfully started"
-	StartRetries int               `yaml:"startretries"` // max restart attempts
-	StopSignal   string            `yaml:"stopsignal"`   // TERM, KILL, USR1, etc.
-	StopTime     int               `yaml:"stoptime"`     // seconds to wait before KILL
-	Stdout       string            `yaml:"stdout"`       // stdout redirection
-	Stderr       string            `yaml:"stderr"`       // stderr redirection
-	Env          map[string]string `yaml:"env"`          // environment variables
-	WorkingDir   string            `yaml:"workingdir"`   // working directory
-	Umask        string            `yaml:"umask"`        // umask for process
+	StartTime    int               `yaml:"starttime"`                   // seconds to consider "successfully started"
+	StartRetries int               `yaml:"startretries"`                // max restart attempts
+	StopSignal   string            `yaml:"stopsignal"`                  // TERM, KILL, USR1, etc.
+	StopTime     int               `yaml:"stoptime"`                    // seconds to wait before KILL
+	Stdout       string            `yaml:"stdout" reload:"restart"`     // stdout redirection
+	Stderr       string            `yaml:"stderr" reload:"restart"`     // stderr redirection
+	Env          map[string]string `yaml:"env" reload:"restart"`        // environment variables
+	WorkingDir   string            `yaml:"workingdir" reload:"restart"` // working directory
+	Umask        string            `yaml:"umask" reload:"restart"`      // umask for process
+	Priority     int               `yaml:"priority"`                    // lower starts first and stops last
+	DependsOn    []string          `yaml:"depends_on"`                  // programs that must be RUNNING first
+	User         string            `yaml:"user" reload:"restart"`       // run as this user (name or UID)
+	Group        string            `yaml:"group" reload:"restart"`      // primary group (name or GID)
+	Groups       []string          `yaml:"groups" reload:"restart"`     // supplementary groups
+
+	BackoffInitial    int     `yaml:"backoff_initial"`    // seconds before the first restart
+	BackoffMax        int     `yaml:"backoff_max"`        // upper bound for the restart delay
+	BackoffMultiplier float64 `yaml:"backoff_multiplier"` // growth factor between attempts
+	BackoffJitter     float64 `yaml:"backoff_jitter"`     // random ± fraction applied to the delay
+
+	StdoutLogfileMaxBytes ByteSize `yaml:"stdout_logfile_maxbytes"` // rotate stdout past this size (0 = never)
+	StdoutLogfileBackups  int      `yaml:"stdout_logfile_backups"`  // rotated stdout files to keep
+	StdoutLogfileCompress bool     `yaml:"stdout_logfile_compress"` // gzip rotated stdout files
+	StderrLogfileMaxBytes ByteSize `yaml:"stderr_logfile_maxbytes"`
+	StderrLogfileBackups  int      `yaml:"stderr_logfile_backups"`
+	StderrLogfileCompress bool     `yaml:"stderr_logfile_compress"`
+
+	MaxRSS        ByteSize `yaml:"max_rss"`         // restart past this resident memory (0 = no limit)
+	MaxCPUPercent float64  `yaml:"max_cpu_percent"` // restart past this CPU usage, 100 = one core (0 = no limit)
+	MaxCPUWindow  int      `yaml:"max_cpu_window"`  // seconds max_cpu_percent must be exceeded in a row
+	MaxOpenFiles  int      `yaml:"max_open_files"`  // restart past this many open descriptors (0 = no limit)
+
+	Rlimits   Rlimits  `yaml:"rlimits" reload:"restart"` // setrlimit(2) limits applied before exec
+	MemoryMax ByteSize `yaml:"memory_max"`               // cgroup v2 memory.max for the whole program (0 = no limit)
+	CPUMax    float64  `yaml:"cpu_max"`                  // cgroup v2 cpu.max in CPUs, e.g. 1.5 (0 = no limit)
+	PidsMax   int      `yaml:"pids_max"`                 // cgroup v2 pids.max (0 = no limit)
+}
+
+// HasCgroupLimits indica si el programa fija algún límite de cgroup v2
+func (p Program) HasCgroupLimits() bool {
+	return p.MemoryMax > 0 || p.CPUMax > 0 || p.PidsMax > 0
 }
 
+// CgroupLimited devuelve, ordenados, los programas que fijan límites de cgroup v2
+func (c *Config) CgroupLimited() []string {
+	var names []string
+	for name, program := range c.Programs {
+		if program.HasCgroupLimits() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load lee, completa con valores por defecto y valida un fichero de
+// configuración. Los errores de validación se devuelven todos a la vez como
+// *ValidationErrors.
 func Load(filename string) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	// Validar la estructura antes de decodificar para informar de todas las
+	// claves desconocidas y valores mal tipados con su línea y columna
 	var config Config
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, err
+	doc := documentNode(&root)
+	v := newValidator(doc)
+	if doc != nil {
+		v.checkNode(doc, reflect.TypeOf(config), "")
+		if err := doc.Decode(&config); err != nil && len(v.errors) == 0 {
+			v.add(doc, "", "%s", decodeMessage(err))
+		}
 	}
 
 	// Aplicar valores por defecto
@@ -53,7 +123,8 @@ func Load(filename string) (*Config, error) {
 		if program.StartTime == 0 {
 			program.StartTime = 1
 		}
-		if program.StartRetries == 0 {
+		// startretries: 0 no reintenta los arranques fallidos
+		if !v.hasProgramField(name, "startretries") {
 			program.StartRetries = 3
 		}
 		if program.AutoRestart == "" {
@@ -65,10 +136,41 @@ func Load(filename string) (*Config, error) {
 		if program.Umask == "" {
 			program.Umask = "022"
 		}
+		if program.Priority == 0 {
+			program.Priority = DefaultPriority
+		}
+		if program.BackoffInitial == 0 {
+			program.BackoffInitial = 1
+		}
+		if program.BackoffMax == 0 {
+			program.BackoffMax = 60
+		}
+		if program.BackoffMultiplier == 0 {
+			program.BackoffMultiplier = 2
+		}
+		// backoff_jitter: 0 desactiva la variación aleatoria
+		if !v.hasProgramField(name, "backoff_jitter") {
+			program.BackoffJitter = 0.1
+		}
+		// *_logfile_backups: 0 trunca el fichero al rotar en lugar de conservarlo
+		if !v.hasProgramField(name, "stdout_logfile_backups") {
+			program.StdoutLogfileBackups = DefaultLogfileBackups
+		}
+		if !v.hasProgramField(name, "stderr_logfile_backups") {
+			program.StderrLogfileBackups = DefaultLogfileBackups
+		}
+		if program.MaxCPUWindow == 0 {
+			program.MaxCPUWindow = 30
+		}
 
 		// Actualizar el mapa con los valores por defecto
 		config.Programs[name] = program
 	}
 
+	config.validatePrograms(v)
+	if err := v.result(filename); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
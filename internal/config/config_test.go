@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadYAML escribe una configuración en un fichero temporal y la carga
+func loadYAML(t *testing.T, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taskmaster.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadBackoffJitter(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want float64
+	}{
+		{"omitted", "cmd: \"true\"", 0.1},
+		{"explicit zero", "cmd: \"true\"\n    backoff_jitter: 0", 0},
+		{"explicit value", "cmd: \"true\"\n    backoff_jitter: 0.5", 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadYAML(t, "programs:\n  app:\n    "+tt.yaml+"\n")
+			if got := cfg.Programs["app"].BackoffJitter; got != tt.want {
+				t.Errorf("BackoffJitter = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStartRetries(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want int
+	}{
+		{"omitted", "", 3},
+		{"explicit zero", "\n    startretries: 0", 0},
+		{"explicit value", "\n    startretries: 5", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadYAML(t, "programs:\n  app:\n    cmd: \"true\""+tt.yaml+"\n")
+			if got := cfg.Programs["app"].StartRetries; got != tt.want {
+				t.Errorf("StartRetries = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLogfileBackups(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaml       string
+		wantStdout int
+		wantStderr int
+	}{
+		{"omitted", "", DefaultLogfileBackups, DefaultLogfileBackups},
+		{"explicit zero", "\n    stdout_logfile_backups: 0", 0, DefaultLogfileBackups},
+		{"both set", "\n    stdout_logfile_backups: 3\n    stderr_logfile_backups: 0", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadYAML(t, "programs:\n  app:\n    cmd: \"true\""+tt.yaml+"\n")
+			program := cfg.Programs["app"]
+			if program.StdoutLogfileBackups != tt.wantStdout || program.StderrLogfileBackups != tt.wantStderr {
+				t.Errorf("backups = %d/%d, want %d/%d", program.StdoutLogfileBackups, program.StderrLogfileBackups,
+					tt.wantStdout, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCgroupLimited(t *testing.T) {
+	cfg := loadYAML(t, `
+programs:
+  web:
+    cmd: "true"
+    memory_max: 256MB
+  worker:
+    cmd: "true"
+    pids_max: 50
+  batch:
+    cmd: "true"
+    cpu_max: 0.5
+  plain:
+    cmd: "true"
+    rlimits:
+      nofile: 1024
+`)
+	got := cfg.CgroupLimited()
+	want := []string{"batch", "web", "worker"}
+	if len(got) != len(want) {
+		t.Fatalf("CgroupLimited() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("CgroupLimited() = %v, want %v", got, want)
+		}
+	}
+
+	if limited := loadYAML(t, "programs:\n  plain:\n    cmd: \"true\"\n").CgroupLimited(); len(limited) != 0 {
+		t.Errorf("CgroupLimited() without limits = %v, want none", limited)
+	}
+}
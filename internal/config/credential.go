@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// Credential es la identidad con la que se ejecutan los procesos de un programa
+type Credential struct {
+	Username string // vacío si sólo se cambia de grupo
+	Home     string
+	UID      uint32
+	GID      uint32
+	Groups   []uint32 // grupos suplementarios
+}
+
+// ResolveCredential resuelve user, group y groups (nombres o IDs numéricos)
+// con os/user y comprueba que taskmaster pueda adoptar esa identidad. Sin
+// ninguno de los tres devuelve nil: el proceso hereda la de taskmaster.
+//
+// Con user, el grupo principal y los suplementarios son los del usuario,
+// salvo que group o groups los sustituyan.
+func ResolveCredential(userName, groupName string, groups []string) (*Credential, error) {
+	if userName == "" && groupName == "" && len(groups) == 0 {
+		return nil, nil
+	}
+
+	credential := &Credential{UID: uint32(os.Geteuid()), GID: uint32(os.Getegid())}
+	if userName != "" {
+		account, err := LookupUser(userName)
+		if err != nil {
+			return nil, err
+		}
+		credential.Username = account.Username
+		credential.Home = account.HomeDir
+		credential.UID = parseID(account.Uid)
+		credential.GID = parseID(account.Gid)
+
+		// Equivalente a initgroups(3): los grupos a los que pertenece el usuario
+		ids, err := account.GroupIds()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list groups of user %q: %w", userName, err)
+		}
+		for _, id := range ids {
+			credential.Groups = append(credential.Groups, parseID(id))
+		}
+	}
+	if groupName != "" {
+		group, err := LookupGroup(groupName)
+		if err != nil {
+			return nil, err
+		}
+		credential.GID = parseID(group.Gid)
+	}
+	if len(groups) > 0 {
+		credential.Groups = nil
+		for _, name := range groups {
+			group, err := LookupGroup(name)
+			if err != nil {
+				return nil, err
+			}
+			credential.Groups = append(credential.Groups, parseID(group.Gid))
+		}
+	}
+
+	if err := credential.checkPrivilege(os.Geteuid(), os.Getegid(), len(groups) > 0); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// checkPrivilege comprueba que taskmaster, con euid y egid, pueda cambiar a la
+// identidad: sin ser root sólo puede conservar la suya y no puede fijar grupos
+// suplementarios
+func (c *Credential) checkPrivilege(euid, egid int, explicitGroups bool) error {
+	switch {
+	case euid == 0:
+		return nil
+	case c.UID != uint32(euid):
+		return fmt.Errorf("taskmaster runs as uid %d and needs root to switch to uid %d", euid, c.UID)
+	case c.GID != uint32(egid):
+		return fmt.Errorf("taskmaster runs as gid %d and needs root to switch to gid %d", egid, c.GID)
+	case explicitGroups:
+		return fmt.Errorf("taskmaster runs as uid %d and needs root to set supplementary groups", euid)
+	}
+	return nil
+}
+
+// LookupUser busca un usuario por nombre o, si es numérico, por UID
+func LookupUser(name string) (*user.User, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if account, err := user.LookupId(name); err == nil {
+			return account, nil
+		}
+	}
+	account, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown user %q", name)
+	}
+	return account, nil
+}
+
+// LookupGroup busca un grupo por nombre o, si es numérico, por GID
+func LookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if group, err := user.LookupGroupId(name); err == nil {
+			return group, nil
+		}
+	}
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown group %q", name)
+	}
+	return group, nil
+}
+
+// parseID convierte un UID o GID de os/user, que en Unix siempre es numérico
+func parseID(id string) uint32 {
+	value, _ := strconv.ParseUint(id, 10, 32)
+	return uint32(value)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLookupNumericIDs(t *testing.T) {
+	account, err := LookupUser("0")
+	if err != nil {
+		t.Fatalf("LookupUser(0) error: %v", err)
+	}
+	if account.Uid != "0" || account.Username != "root" {
+		t.Errorf("LookupUser(0) = %s (uid %s), want root (uid 0)", account.Username, account.Uid)
+	}
+
+	group, err := LookupGroup("0")
+	if err != nil {
+		t.Fatalf("LookupGroup(0) error: %v", err)
+	}
+	if group.Gid != "0" {
+		t.Errorf("LookupGroup(0) gid = %s, want 0", group.Gid)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup func(string) error
+		value  string
+		want   string
+	}{
+		{"user name", func(v string) error { _, err := LookupUser(v); return err }, "taskmaster-no-such-user", `unknown user "taskmaster-no-such-user"`},
+		{"unassigned uid", func(v string) error { _, err := LookupUser(v); return err }, "4000000000", `unknown user "4000000000"`},
+		{"group name", func(v string) error { _, err := LookupGroup(v); return err }, "taskmaster-no-such-group", `unknown group "taskmaster-no-such-group"`},
+		{"unassigned gid", func(v string) error { _, err := LookupGroup(v); return err }, "4000000000", `unknown group "4000000000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.lookup(tt.value); err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ResolveCredential("taskmaster-no-such-user", "", nil); err == nil {
+		t.Errorf("ResolveCredential() with an unknown user succeeded")
+	}
+}
+
+func TestResolveCredentialNumericUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching to uid 0 needs root")
+	}
+	credential, err := ResolveCredential("0", "0", nil)
+	if err != nil {
+		t.Fatalf("ResolveCredential(0, 0) error: %v", err)
+	}
+	if credential.Username != "root" || credential.UID != 0 || credential.GID != 0 {
+		t.Errorf("ResolveCredential(0, 0) = %+v, want root with uid 0 and gid 0", credential)
+	}
+}
+
+func TestCheckPrivilege(t *testing.T) {
+	tests := []struct {
+		name           string
+		euid, egid     int
+		credential     Credential
+		explicitGroups bool
+		want           string
+	}{
+		{"root switches to anyone", 0, 0, Credential{UID: 1000, GID: 1000}, true, ""},
+		{"same identity", 1000, 1000, Credential{UID: 1000, GID: 1000}, false, ""},
+		{"another user", 1000, 1000, Credential{UID: 1001, GID: 1000}, false, "taskmaster runs as uid 1000 and needs root to switch to uid 1001"},
+		{"another group", 1000, 1000, Credential{UID: 1000, GID: 0}, false, "taskmaster runs as gid 1000 and needs root to switch to gid 0"},
+		{"supplementary groups", 1000, 1000, Credential{UID: 1000, GID: 1000}, true, "needs root to set supplementary groups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.credential.checkPrivilege(tt.euid, tt.egid, tt.explicitGroups)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("checkPrivilege() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("checkPrivilege() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
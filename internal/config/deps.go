@@ -0,0 +1,173 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// validateDependencies comprueba que las dependencias existan y no formen ciclos
+func (c *Config) validateDependencies(v *validator) {
+	for _, name := range c.sortedNames() {
+		for i, dep := range c.Programs[name].DependsOn {
+			if dep == name {
+				v.addProgramItem(name, "depends_on", i, "program %s depends on itself", name)
+			} else if _, exists := c.Programs[dep]; !exists {
+				v.addProgramItem(name, "depends_on", i, "program %s depends on unknown program %s", name, dep)
+			}
+		}
+	}
+
+	if cycle := c.findCycle(); cycle != nil {
+		v.addProgram(cycle[0], "depends_on", "dependency cycle detected: %s", strings.Join(cycle, " -> "))
+	}
+}
+
+// findCycle devuelve el primer ciclo de dependencias encontrado, o nil
+func (c *Config) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range c.Programs[name].DependsOn {
+			switch state[dep] {
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						cycle = append(append([]string{}, path[i:]...), dep)
+						break
+					}
+				}
+				return true
+			case unvisited:
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = done
+		return false
+	}
+
+	for _, name := range c.sortedNames() {
+		if state[name] == unvisited && visit(name) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// StartOrder devuelve los programas en orden topológico: cada programa aparece
+// después de sus dependencias y los empates se resuelven por prioridad y nombre
+func (c *Config) StartOrder() []string {
+	pending := make(map[string]int)
+	dependents := make(map[string][]string)
+	for name, program := range c.Programs {
+		pending[name] = len(program.DependsOn)
+		for _, dep := range program.DependsOn {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready []string
+	for name, count := range pending {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(c.Programs))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			return c.startsBefore(ready[i], ready[j])
+		})
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order
+}
+
+// StopWaves devuelve el orden inverso al de arranque agrupando en una misma
+// oleada los programas consecutivos con igual prioridad que no dependen entre sí
+func (c *Config) StopWaves() [][]string {
+	order := c.StartOrder()
+	var waves [][]string
+
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		if len(waves) > 0 && c.canJoinWave(name, waves[len(waves)-1]) {
+			waves[len(waves)-1] = append(waves[len(waves)-1], name)
+			continue
+		}
+		waves = append(waves, []string{name})
+	}
+	return waves
+}
+
+// canJoinWave indica si un programa puede detenerse junto con los de la oleada
+func (c *Config) canJoinWave(name string, wave []string) bool {
+	for _, member := range wave {
+		if c.Programs[member].Priority != c.Programs[name].Priority || c.DependsOn(member, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// DependsOn indica si el programa name depende, directa o indirectamente, de dep
+func (c *Config) DependsOn(name, dep string) bool {
+	seen := make(map[string]bool)
+	stack := append([]string{}, c.Programs[name].DependsOn...)
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == dep {
+			return true
+		}
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		stack = append(stack, c.Programs[current].DependsOn...)
+	}
+	return false
+}
+
+// startsBefore compara dos programas por prioridad y, a igualdad, por nombre
+func (c *Config) startsBefore(a, b string) bool {
+	pa, pb := c.Programs[a].Priority, c.Programs[b].Priority
+	if pa != pb {
+		return pa < pb
+	}
+	return a < b
+}
+
+// sortedNames devuelve los nombres de los programas ordenados alfabéticamente
+func (c *Config) sortedNames() []string {
+	names := make([]string, 0, len(c.Programs))
+	for name := range c.Programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// graph crea una configuración con los programas, prioridades y dependencias indicados
+func graph(programs map[string]Program) *Config {
+	return &Config{Programs: programs}
+}
+
+func dep(priority int, dependsOn ...string) Program {
+	return Program{Cmd: "true", Priority: priority, DependsOn: dependsOn}
+}
+
+func TestStartOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		programs map[string]Program
+		want     []string
+	}{
+		{
+			name:     "priority then name",
+			programs: map[string]Program{"b": dep(10), "a": dep(10), "c": dep(5)},
+			want:     []string{"c", "a", "b"},
+		},
+		{
+			name:     "dependency overrides priority",
+			programs: map[string]Program{"app": dep(1, "db"), "db": dep(999)},
+			want:     []string{"db", "app"},
+		},
+		{
+			name: "chain and diamond",
+			programs: map[string]Program{
+				"web":   dep(1, "api", "cache"),
+				"api":   dep(1, "db"),
+				"cache": dep(1, "db"),
+				"db":    dep(1),
+				"cron":  dep(2),
+			},
+			want: []string{"db", "api", "cache", "web", "cron"},
+		},
+		{
+			name:     "independent programs keep priority order",
+			programs: map[string]Program{"worker": dep(20, "queue"), "queue": dep(10), "ui": dep(15)},
+			want:     []string{"queue", "ui", "worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graph(tt.programs).StartOrder(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StartOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopWaves(t *testing.T) {
+	tests := []struct {
+		name     string
+		programs map[string]Program
+		want     [][]string
+	}{
+		{
+			name:     "same priority stops together",
+			programs: map[string]Program{"a": dep(10), "b": dep(10), "c": dep(5)},
+			want:     [][]string{{"b", "a"}, {"c"}},
+		},
+		{
+			name: "dependents stop first",
+			programs: map[string]Program{
+				"web":  dep(1, "api"),
+				"api":  dep(1, "db"),
+				"db":   dep(1),
+				"cron": dep(1),
+			},
+			want: [][]string{{"web"}, {"api"}, {"db", "cron"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graph(tt.programs).StopWaves(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StopWaves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	cfg := graph(map[string]Program{
+		"web": dep(1, "api"),
+		"api": dep(1, "db"),
+		"db":  dep(1),
+		"ui":  dep(1),
+	})
+
+	tests := []struct {
+		name, dep string
+		want      bool
+	}{
+		{"web", "api", true},
+		{"web", "db", true},
+		{"db", "web", false},
+		{"ui", "db", false},
+		{"web", "web", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.DependsOn(tt.name, tt.dep); got != tt.want {
+			t.Errorf("DependsOn(%s, %s) = %v, want %v", tt.name, tt.dep, got, tt.want)
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		programs map[string]Program
+		want     []string
+	}{
+		{
+			name:     "acyclic",
+			programs: map[string]Program{"a": dep(1, "b"), "b": dep(1, "c"), "c": dep(1)},
+			want:     nil,
+		},
+		{
+			name:     "two programs",
+			programs: map[string]Program{"a": dep(1, "b"), "b": dep(1, "a")},
+			want:     []string{"a", "b", "a"},
+		},
+		{
+			name:     "cycle below an acyclic entry",
+			programs: map[string]Program{"app": dep(1, "x"), "x": dep(1, "y"), "y": dep(1, "z"), "z": dep(1, "x")},
+			want:     []string{"x", "y", "z", "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graph(tt.programs).findCycle(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDependencyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		programs string
+		want     string
+	}{
+		{
+			name:     "cycle",
+			programs: "  a:\n    cmd: \"true\"\n    depends_on: [b]\n  b:\n    cmd: \"true\"\n    depends_on: [a]\n",
+			want:     "dependency cycle detected: a -> b -> a",
+		},
+		{
+			name:     "self",
+			programs: "  a:\n    cmd: \"true\"\n    depends_on: [a]\n",
+			want:     "program a depends on itself",
+		},
+		{
+			name:     "unknown",
+			programs: "  a:\n    cmd: \"true\"\n    depends_on: [ghost]\n",
+			want:     "program a depends on unknown program ghost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "taskmaster.yml")
+			if err := os.WriteFile(path, []byte("programs:\n"+tt.programs), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
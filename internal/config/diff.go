@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Formas de aplicar el cambio de un campo durante una recarga
+const (
+	ApplyLive    = "live"
+	ApplyRestart = "restart"
+	ApplyScale   = "scale"
+)
+
+// FieldChange describe un campo de un programa que cambió entre dos configuraciones
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	Apply string `json:"apply"`
+}
+
+// DiffPrograms compara dos programas campo a campo y clasifica cada cambio
+// según la etiqueta reload del campo
+func DiffPrograms(old, new Program) []FieldChange {
+	var changes []FieldChange
+
+	oldValue := reflect.ValueOf(old)
+	newValue := reflect.ValueOf(new)
+	programType := oldValue.Type()
+
+	for i := 0; i < programType.NumField(); i++ {
+		field := programType.Field(i)
+		a, b := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if reflect.DeepEqual(a, b) || (isEmpty(oldValue.Field(i)) && isEmpty(newValue.Field(i))) {
+			continue
+		}
+
+		apply := field.Tag.Get("reload")
+		if apply == "" {
+			apply = ApplyLive
+		}
+
+		changes = append(changes, FieldChange{
+			Field: yamlName(field),
+			Old:   formatValue(a),
+			New:   formatValue(b),
+			Apply: apply,
+		})
+	}
+	return changes
+}
+
+// yamlName devuelve el nombre del campo tal y como aparece en el YAML
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// isEmpty trata como equivalentes los slices y mapas nil y vacíos
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return false
+}
+
+// formatValue representa un valor de configuración de forma legible
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPrograms(t *testing.T) {
+	base := Program{Cmd: "sleep 10", NumProcs: 1, AutoRestart: "unexpected", ExitCodes: []int{0}}
+	nofile := Rlimit(1024)
+
+	tests := []struct {
+		name   string
+		modify func(p *Program)
+		want   []FieldChange
+	}{
+		{"unchanged", func(p *Program) {}, nil},
+		{"nil and empty env are equal", func(p *Program) { p.Env = map[string]string{} }, nil},
+		{"cmd requires restart", func(p *Program) { p.Cmd = "sleep 20" },
+			[]FieldChange{{Field: "cmd", Old: `"sleep 10"`, New: `"sleep 20"`, Apply: ApplyRestart}}},
+		{"numprocs scales", func(p *Program) { p.NumProcs = 3 },
+			[]FieldChange{{Field: "numprocs", Old: "1", New: "3", Apply: ApplyScale}}},
+		{"autorestart is live", func(p *Program) { p.AutoRestart = "always" },
+			[]FieldChange{{Field: "autorestart", Old: `"unexpected"`, New: `"always"`, Apply: ApplyLive}}},
+		{"rlimits require restart", func(p *Program) { p.Rlimits.NoFile = &nofile },
+			[]FieldChange{{Field: "rlimits", Old: "none", New: "nofile=1024", Apply: ApplyRestart}}},
+		{"several fields in declaration order", func(p *Program) {
+			p.StopTime = 5
+			p.Cmd = "sleep 20"
+		}, []FieldChange{
+			{Field: "cmd", Old: `"sleep 10"`, New: `"sleep 20"`, Apply: ApplyRestart},
+			{Field: "stoptime", Old: "0", New: "5", Apply: ApplyLive},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := base
+			modified.ExitCodes = append([]int(nil), base.ExitCodes...)
+			tt.modify(&modified)
+			if got := DiffPrograms(base, modified); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffPrograms() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
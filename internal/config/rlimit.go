@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RlimitUnlimited es el valor RLIM_INFINITY, que en el YAML se escribe "unlimited"
+const RlimitUnlimited = Rlimit(^uint64(0))
+
+// Rlimit es el valor de un límite setrlimit(2): un entero, un tamaño con
+// sufijo (1GB) o "unlimited"
+type Rlimit uint64
+
+// UnmarshalYAML implementa yaml.Unmarshaler
+func (r *Rlimit) UnmarshalYAML(value *yaml.Node) error {
+	if strings.EqualFold(strings.TrimSpace(value.Value), "unlimited") {
+		*r = RlimitUnlimited
+		return nil
+	}
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return fmt.Errorf("invalid limit %q (use a number, a KB/MB/GB size or unlimited)", value.Value)
+	}
+	*r = Rlimit(size)
+	return nil
+}
+
+// String muestra el límite tal y como se escribe en el YAML
+func (r Rlimit) String() string {
+	if r == RlimitUnlimited {
+		return "unlimited"
+	}
+	return strconv.FormatUint(uint64(r), 10)
+}
+
+// Rlimits son los límites setrlimit(2) de los procesos de un programa; los
+// que no se indican se heredan del supervisor
+type Rlimits struct {
+	NoFile *Rlimit `yaml:"nofile"` // descriptores abiertos
+	NProc  *Rlimit `yaml:"nproc"`  // procesos del usuario real
+	Core   *Rlimit `yaml:"core"`   // tamaño de los core dumps (0 = desactivados)
+	AS     *Rlimit `yaml:"as"`     // espacio de direcciones
+}
+
+// String lista los límites configurados, para el informe de recarga
+func (r Rlimits) String() string {
+	var parts []string
+	for _, limit := range []struct {
+		name  string
+		value *Rlimit
+	}{{"nofile", r.NoFile}, {"nproc", r.NProc}, {"core", r.Core}, {"as", r.AS}} {
+		if limit.value != nil {
+			parts = append(parts, limit.name+"="+limit.value.String())
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Empty indica si no hay ningún límite configurado
+func (r Rlimits) Empty() bool {
+	return r.NoFile == nil && r.NProc == nil && r.Core == nil && r.AS == nil
+}
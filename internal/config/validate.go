@@ -0,0 +1,439 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"taskmaster/pkg/signals"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError describe un problema de la configuración y su posición en el YAML
+type ValidationError struct {
+	Line    int
+	Column  int
+	Field   string // ruta del campo, p. ej. programs.web.stopsignal
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Field, e.Message)
+}
+
+// ValidationErrors agrupa todos los problemas encontrados en un fichero
+type ValidationErrors struct {
+	File   string
+	Errors []ValidationError
+}
+
+func (e *ValidationErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration error(s) in %s:", len(e.Errors), e.File)
+	for _, err := range e.Errors {
+		if err.Line == 0 {
+			fmt.Fprintf(&b, "\n  %s: %s: %s", e.File, err.Field, err.Message)
+			continue
+		}
+		fmt.Fprintf(&b, "\n  %s:%d:%d: %s: %s", e.File, err.Line, err.Column, err.Field, err.Message)
+	}
+	return b.String()
+}
+
+// validAutoRestart son los valores aceptados por autorestart
+var validAutoRestart = []string{"always", "never", "unexpected"}
+
+// yamlLinePrefix es el prefijo de posición que yaml añade a sus mensajes
+var yamlLinePrefix = regexp.MustCompile(`^line \d+: `)
+
+// validator acumula los errores de validación junto a los nodos YAML de cada
+// programa, para poder situar en el fichero los problemas semánticos
+type validator struct {
+	errors   []ValidationError
+	programs map[string]*programNodes
+}
+
+// programNodes guarda el nodo de la clave de un programa y los de sus campos
+type programNodes struct {
+	key    *yaml.Node
+	fields map[string]*yaml.Node
+}
+
+func newValidator(doc *yaml.Node) *validator {
+	v := &validator{programs: make(map[string]*programNodes)}
+	if doc == nil || doc.Kind != yaml.MappingNode {
+		return v
+	}
+
+	programs := mappingValue(doc, "programs")
+	if programs == nil || programs.Kind != yaml.MappingNode {
+		return v
+	}
+	for i := 0; i+1 < len(programs.Content); i += 2 {
+		nodes := &programNodes{key: programs.Content[i], fields: make(map[string]*yaml.Node)}
+		if value := programs.Content[i+1]; value.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				nodes.fields[value.Content[j].Value] = value.Content[j+1]
+			}
+		}
+		v.programs[programs.Content[i].Value] = nodes
+	}
+	return v
+}
+
+// add registra un error en la posición del nodo indicado
+func (v *validator) add(node *yaml.Node, field, format string, args ...interface{}) {
+	if field == "" {
+		field = "document"
+	}
+	err := ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		err.Line, err.Column = node.Line, node.Column
+	}
+	v.errors = append(v.errors, err)
+}
+
+// addProgram registra un error sobre un campo de un programa; si el campo no
+// aparece en el fichero se sitúa en la clave del programa
+func (v *validator) addProgram(program, key, format string, args ...interface{}) {
+	v.add(v.programNode(program, key), "programs."+program+"."+key, format, args...)
+}
+
+// addProgramItem registra un error sobre un elemento de una lista de un programa
+func (v *validator) addProgramItem(program, key string, index int, format string, args ...interface{}) {
+	node := v.programNode(program, key)
+	if node != nil && node.Kind == yaml.SequenceNode && index < len(node.Content) {
+		node = node.Content[index]
+	}
+	v.add(node, fmt.Sprintf("programs.%s.%s[%d]", program, key, index), format, args...)
+}
+
+// programNode devuelve el nodo del campo de un programa, o el de su clave
+func (v *validator) programNode(program, key string) *yaml.Node {
+	nodes, exists := v.programs[program]
+	if !exists {
+		return nil
+	}
+	if node, exists := nodes.fields[key]; exists {
+		return node
+	}
+	return nodes.key
+}
+
+// hasProgramField indica si un campo de un programa aparece en el fichero,
+// para distinguir un 0 explícito de un campo omitido
+func (v *validator) hasProgramField(program, key string) bool {
+	nodes, exists := v.programs[program]
+	if !exists {
+		return false
+	}
+	_, exists = nodes.fields[key]
+	return exists
+}
+
+// result devuelve los errores ordenados por posición, o nil si no hay ninguno
+func (v *validator) result(filename string) error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		a, b := v.errors[i], v.errors[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return &ValidationErrors{File: filename, Errors: v.errors}
+}
+
+// checkNode recorre el árbol YAML contra el tipo Go de destino, detectando
+// claves desconocidas y valores que no encajan en el tipo del campo
+func (v *validator) checkNode(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.ShortTag() == "!!null" {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, path, "expected a mapping")
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, exists := fields[key.Value]
+			if !exists {
+				v.add(key, joinPath(path, key.Value), "unknown field%s", suggestion(key.Value, fields))
+				continue
+			}
+			v.checkNode(value, field.Type, joinPath(path, key.Value))
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, path, "expected a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.add(node, path, "expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			v.checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			v.add(node, path, "%s", decodeMessage(err))
+		}
+	}
+}
+
+// validatePrograms comprueba los valores de cada programa una vez aplicados
+// los valores por defecto
+func (c *Config) validatePrograms(v *validator) {
+	for _, name := range c.sortedNames() {
+		program := c.Programs[name]
+
+		if strings.TrimSpace(program.Cmd) == "" {
+			v.addProgram(name, "cmd", "cmd is required")
+		}
+		if program.NumProcs < 1 {
+			v.addProgram(name, "numprocs", "must be at least 1, got %d", program.NumProcs)
+		}
+		if !contains(validAutoRestart, program.AutoRestart) {
+			v.addProgram(name, "autorestart", "invalid value %q (valid: %s)",
+				program.AutoRestart, strings.Join(validAutoRestart, ", "))
+		}
+		for i, code := range program.ExitCodes {
+			if code < 0 || code > 255 {
+				v.addProgramItem(name, "exitcodes", i, "exit code %d out of range 0-255", code)
+			}
+		}
+		if program.StartTime < 0 {
+			v.addProgram(name, "starttime", "must not be negative, got %d", program.StartTime)
+		}
+		if program.StartRetries < 0 {
+			v.addProgram(name, "startretries", "must not be negative, got %d", program.StartRetries)
+		}
+		if program.StopTime < 0 {
+			v.addProgram(name, "stoptime", "must not be negative, got %d", program.StopTime)
+		}
+		if !signals.IsValidSignal(program.StopSignal) {
+			valid := signals.ValidSignals()
+			sort.Strings(valid)
+			v.addProgram(name, "stopsignal", "unknown signal %q (valid: %s)",
+				program.StopSignal, strings.Join(valid, ", "))
+		}
+		if mask, err := strconv.ParseUint(program.Umask, 8, 32); err != nil || mask > 0777 {
+			v.addProgram(name, "umask", "invalid octal umask %q", program.Umask)
+		}
+		if program.WorkingDir != "" {
+			if info, err := os.Stat(program.WorkingDir); err != nil {
+				v.addProgram(name, "workingdir", "directory %q does not exist", program.WorkingDir)
+			} else if !info.IsDir() {
+				v.addProgram(name, "workingdir", "%q is not a directory", program.WorkingDir)
+			}
+		}
+		checkCredential(v, name, program)
+		checkLogDir(v, name, "stdout", program.Stdout)
+		checkLogDir(v, name, "stderr", program.Stderr)
+		if program.StdoutLogfileBackups < 0 {
+			v.addProgram(name, "stdout_logfile_backups", "must not be negative, got %d", program.StdoutLogfileBackups)
+		}
+		if program.StderrLogfileBackups < 0 {
+			v.addProgram(name, "stderr_logfile_backups", "must not be negative, got %d", program.StderrLogfileBackups)
+		}
+
+		if program.BackoffInitial < 0 {
+			v.addProgram(name, "backoff_initial", "must not be negative, got %d", program.BackoffInitial)
+		}
+		if program.BackoffMax < program.BackoffInitial {
+			v.addProgram(name, "backoff_max", "must be at least backoff_initial (%d), got %d",
+				program.BackoffInitial, program.BackoffMax)
+		}
+		if program.BackoffMultiplier < 1 {
+			v.addProgram(name, "backoff_multiplier", "must be at least 1, got %g", program.BackoffMultiplier)
+		}
+		if program.BackoffJitter < 0 || program.BackoffJitter > 1 {
+			v.addProgram(name, "backoff_jitter", "must be between 0 and 1, got %g", program.BackoffJitter)
+		}
+
+		if program.MaxCPUPercent < 0 {
+			v.addProgram(name, "max_cpu_percent", "must not be negative, got %g", program.MaxCPUPercent)
+		}
+		if program.MaxCPUWindow < 0 {
+			v.addProgram(name, "max_cpu_window", "must not be negative, got %d", program.MaxCPUWindow)
+		}
+		if program.MaxOpenFiles < 0 {
+			v.addProgram(name, "max_open_files", "must not be negative, got %d", program.MaxOpenFiles)
+		}
+		if program.CPUMax < 0 {
+			v.addProgram(name, "cpu_max", "must not be negative, got %g", program.CPUMax)
+		}
+		if program.PidsMax < 0 {
+			v.addProgram(name, "pids_max", "must not be negative, got %d", program.PidsMax)
+		}
+	}
+
+	c.validateDependencies(v)
+}
+
+// checkCredential comprueba que user, group y groups existan y que taskmaster
+// pueda cambiar a esa identidad
+func checkCredential(v *validator, program string, p Program) {
+	resolved := true
+	if p.User != "" {
+		if _, err := LookupUser(p.User); err != nil {
+			v.addProgram(program, "user", "%v", err)
+			resolved = false
+		}
+	}
+	if p.Group != "" {
+		if _, err := LookupGroup(p.Group); err != nil {
+			v.addProgram(program, "group", "%v", err)
+			resolved = false
+		}
+	}
+	for i, name := range p.Groups {
+		if _, err := LookupGroup(name); err != nil {
+			v.addProgramItem(program, "groups", i, "%v", err)
+			resolved = false
+		}
+	}
+	if !resolved {
+		return
+	}
+
+	if _, err := ResolveCredential(p.User, p.Group, p.Groups); err != nil {
+		key := "groups"
+		if p.User != "" {
+			key = "user"
+		} else if p.Group != "" {
+			key = "group"
+		}
+		v.addProgram(program, key, "%v", err)
+	}
+}
+
+// checkLogDir comprueba que exista el directorio donde se creará un fichero de salida
+func checkLogDir(v *validator, program, key, path string) {
+	if path == "" {
+		return
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		v.addProgram(program, key, "directory %q does not exist", dir)
+	}
+}
+
+// yamlFields indexa los campos de un struct por su nombre en YAML
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fields[yamlName(field)] = field
+	}
+	return fields
+}
+
+// suggestion propone el campo conocido más parecido a una clave desconocida
+func suggestion(key string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance calcula la distancia de Levenshtein entre dos cadenas
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+// decodeMessage extrae el mensaje de un error de yaml sin su prefijo de línea,
+// ya que la posición se informa por separado
+func decodeMessage(err error) string {
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return err.Error()
+	}
+	messages := make([]string, len(typeErr.Errors))
+	for i, msg := range typeErr.Errors {
+		messages[i] = yamlLinePrefix.ReplaceAllString(msg, "")
+	}
+	return strings.Join(messages, "; ")
+}
+
+// documentNode devuelve el nodo raíz del documento, o nil si está vacío
+func documentNode(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		return root.Content[0]
+	}
+	return nil
+}
+
+// mappingValue devuelve el valor asociado a una clave de un mapping
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadErrors carga una configuración que debe ser inválida y devuelve sus errores
+func loadErrors(t *testing.T, content string) []ValidationError {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taskmaster.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	var validationErrs *ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Load() error = %v, want *ValidationErrors", err)
+	}
+	return validationErrs.Errors
+}
+
+func TestLoadValidationErrors(t *testing.T) {
+	// Lo que se añade tras header empieza en la línea 4
+	const header = "programs:\n  web:\n    cmd: \"true\"\n"
+
+	tests := []struct {
+		name    string
+		yaml    string
+		at      string // línea:columna
+		field   string
+		message string
+	}{
+		{
+			name:    "unknown field with suggestion",
+			yaml:    header + "    stopsignall: TERM\n",
+			at:      "4:5",
+			field:   "programs.web.stopsignall",
+			message: `unknown field (did you mean "stopsignal"?)`,
+		},
+		{
+			name:    "unknown field without suggestion",
+			yaml:    header + "    colour: blue\n",
+			at:      "4:5",
+			field:   "programs.web.colour",
+			message: "unknown field",
+		},
+		{
+			name:    "unknown top-level field",
+			yaml:    "programz:\n  web:\n    cmd: \"true\"\n",
+			at:      "1:1",
+			field:   "programz",
+			message: `unknown field (did you mean "programs"?)`,
+		},
+		{
+			name:    "wrong type",
+			yaml:    header + "    numprocs: many\n",
+			at:      "4:15",
+			field:   "programs.web.numprocs",
+			message: "cannot unmarshal !!str `many` into int",
+		},
+		{
+			name:    "missing cmd is reported on the program key",
+			yaml:    "programs:\n  web:\n    numprocs: 2\n",
+			at:      "2:3",
+			field:   "programs.web.cmd",
+			message: "cmd is required",
+		},
+		{
+			name:    "unknown signal",
+			yaml:    header + "    stopsignal: FOO\n",
+			at:      "4:17",
+			field:   "programs.web.stopsignal",
+			message: `unknown signal "FOO"`,
+		},
+		{
+			name:    "umask out of range",
+			yaml:    header + "    umask: \"1777\"\n",
+			at:      "4:12",
+			field:   "programs.web.umask",
+			message: `invalid octal umask "1777"`,
+		},
+		{
+			name:    "umask not octal",
+			yaml:    header + "    umask: \"089\"\n",
+			at:      "4:12",
+			field:   "programs.web.umask",
+			message: `invalid octal umask "089"`,
+		},
+		{
+			name:    "missing workingdir",
+			yaml:    header + "    workingdir: /nonexistent/taskmaster\n",
+			at:      "4:17",
+			field:   "programs.web.workingdir",
+			message: `directory "/nonexistent/taskmaster" does not exist`,
+		},
+		{
+			name:    "workingdir is a file",
+			yaml:    header + "    workingdir: /dev/null\n",
+			at:      "4:17",
+			field:   "programs.web.workingdir",
+			message: `"/dev/null" is not a directory`,
+		},
+		{
+			name:    "invalid autorestart",
+			yaml:    header + "    autorestart: sometimes\n",
+			at:      "4:18",
+			field:   "programs.web.autorestart",
+			message: `invalid value "sometimes" (valid: always, never, unexpected)`,
+		},
+		{
+			name:    "exit code out of range points at the item",
+			yaml:    header + "    exitcodes: [0, 300]\n",
+			at:      "4:20",
+			field:   "programs.web.exitcodes[1]",
+			message: "exit code 300 out of range 0-255",
+		},
+		{
+			name:    "unknown dependency points at the item",
+			yaml:    header + "    depends_on:\n      - web_db\n",
+			at:      "5:9",
+			field:   "programs.web.depends_on[0]",
+			message: "program web depends on unknown program web_db",
+		},
+		{
+			name:    "negative logfile backups",
+			yaml:    header + "    stdout_logfile_backups: -1\n",
+			at:      "4:29",
+			field:   "programs.web.stdout_logfile_backups",
+			message: "must not be negative, got -1",
+		},
+		{
+			name:    "negative startretries",
+			yaml:    header + "    startretries: -1\n",
+			at:      "4:19",
+			field:   "programs.web.startretries",
+			message: "must not be negative, got -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := loadErrors(t, tt.yaml)
+			if len(errs) != 1 {
+				t.Fatalf("Load() returned %d errors, want 1: %v", len(errs), errs)
+			}
+			got := errs[0]
+			if at := fmt.Sprintf("%d:%d", got.Line, got.Column); at != tt.at || got.Field != tt.field {
+				t.Errorf("error at %s %s, want %s %s", at, got.Field, tt.at, tt.field)
+			}
+			if !strings.HasPrefix(got.Message, tt.message) {
+				t.Errorf("message = %q, want it to start with %q", got.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestLoadValidationErrorOrder(t *testing.T) {
+	errs := loadErrors(t, `programs:
+  web:
+    cmd: "true"
+    stopsignal: FOO
+    colour: blue
+  api:
+    numprocs: 0
+    autorestart: sometimes
+`)
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Field)
+	}
+	// Ordenados por posición en el fichero, no por programa ni por regla
+	want := []string{
+		"programs.web.stopsignal",
+		"programs.web.colour",
+		"programs.api.cmd",
+		"programs.api.autorestart",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("error fields = %v, want %v", got, want)
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	fields := yamlFields(reflect.TypeOf(Program{}))
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"cmdd", ` (did you mean "cmd"?)`},
+		{"numproc", ` (did you mean "numprocs"?)`},
+		{"auto_start", ` (did you mean "autostart"?)`},
+		{"somethingelse", ""},
+	}
+	for _, tt := range tests {
+		if got := suggestion(tt.key, fields); got != tt.want {
+			t.Errorf("suggestion(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Call sends a single request to the daemon listening on socketPath and waits
+// for its response. The protocol version is filled in automatically.
+func Call(socketPath string, req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to taskmaster at %s: %w", socketPath, err)
+	}
+	defer conn.Close()
+
+	req.Version = ProtocolVersion
+	if err := json.NewEncoder(conn).Encode(&req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.Version != ProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d (client speaks %d)", resp.Version, ProtocolVersion)
+	}
+
+	return &resp, nil
+}
+
+// Follow sends a streaming request (such as "tail -f") and calls handle for
+// every response until the daemon closes the connection or handle returns false.
+func Follow(socketPath string, req Request, handle func(*Response) bool) error {
+	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("cannot connect to taskmaster at %s: %w", socketPath, err)
+	}
+	defer conn.Close()
+
+	req.Version = ProtocolVersion
+	if err := json.NewEncoder(conn).Encode(&req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var resp Response
+		if err := decoder.Decode(&resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.Version != ProtocolVersion {
+			return fmt.Errorf("unsupported protocol version %d (client speaks %d)", resp.Version, ProtocolVersion)
+		}
+		if !handle(&resp) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+
+	"taskmaster/internal/process"
+)
+
+// ProtocolVersion is the version of the request/response protocol spoken on the
+// control socket. Servers reject requests carrying a different version.
+const ProtocolVersion = 1
+
+// DefaultSocketPath returns the control socket used when none is configured:
+// /run/taskmaster.sock for root and taskmaster.sock in $XDG_RUNTIME_DIR for
+// other users. It is empty when neither applies; a world-writable directory
+// such as /tmp would let another user take the path first.
+func DefaultSocketPath() string {
+	if os.Geteuid() == 0 {
+		return "/run/taskmaster.sock"
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "taskmaster.sock")
+	}
+	return ""
+}
+
+// DryRunFlag is the reload argument that only computes the reload plan.
+const DryRunFlag = "--dry-run"
+
+// Exit codes returned by taskmasterctl so scripts can tell failures apart.
+const (
+	ExitOK          = 0 // every requested operation succeeded
+	ExitFailure     = 1 // the command ran but at least one program failed
+	ExitUsage       = 2 // invalid command line
+	ExitUnavailable = 3 // daemon unreachable or protocol error
+)
+
+// CodeProtocol marks responses to requests the server could not understand,
+// such as a different protocol version or malformed JSON.
+const CodeProtocol = "protocol_error"
+
+// Request is a single command sent by a client. Each connection carries
+// exactly one request followed by one response, both encoded as JSON. The only
+// exception is "tail -f", which keeps answering with one response per batch of
+// new output lines until the client closes the connection.
+type Request struct {
+	Version int      `json:"version"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Result reports the outcome of a command for one program.
+type Result struct {
+	Target string `json:"target"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Response is the server answer to a Request. Error is set when the request as
+// a whole could not be processed; per-program failures are reported in Results.
+type Response struct {
+	Version int                    `json:"version"`
+	OK      bool                   `json:"ok"`
+	Error   string                 `json:"error,omitempty"`
+	Code    string                 `json:"code,omitempty"` // CodeProtocol for protocol errors
+	Results []Result               `json:"results,omitempty"`
+	Status  []process.InstanceInfo `json:"status,omitempty"`
+	Reload  *process.ReloadReport  `json:"reload,omitempty"`
+	Output  []process.OutputLine   `json:"output,omitempty"`
+}
+
+// ExitCode maps a response to the exit code taskmasterctl should return.
+func (r *Response) ExitCode() int {
+	if r.OK {
+		return ExitOK
+	}
+	if r.Code == CodeProtocol {
+		return ExitUnavailable
+	}
+	return ExitFailure
+}
@@ -0,0 +1,22 @@
+package control
+
+import "testing"
+
+func TestResponseExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		resp Response
+		want int
+	}{
+		{"ok", Response{OK: true}, ExitOK},
+		{"command failed", Response{Error: "program not found"}, ExitFailure},
+		{"protocol error", Response{Error: "unsupported protocol version 2", Code: CodeProtocol}, ExitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resp.ExitCode(); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"taskmaster/internal/logger"
+	"taskmaster/internal/process"
+)
+
+// Server answers taskmasterctl requests on a Unix socket.
+type Server struct {
+	path       string
+	manager    *process.Manager
+	logger     *logger.Logger
+	configFile string
+	listener   net.Listener
+	mutex      sync.Mutex
+	closed     bool
+}
+
+// NewServer creates a server for the socket at path; it does not listen until
+// Start is called.
+func NewServer(path string, manager *process.Manager, logger *logger.Logger) *Server {
+	return &Server{
+		path:    path,
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+// SetConfigFile sets the configuration file used by the reload command.
+func (s *Server) SetConfigFile(configFile string) {
+	s.configFile = configFile
+}
+
+// Start listens on the Unix socket and serves requests until Close is called.
+func (s *Server) Start() error {
+	if err := s.removeStaleSocket(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.path, err)
+	}
+	if err := os.Chmod(s.path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set permissions on %s: %w", s.path, err)
+	}
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		listener.Close()
+		return nil
+	}
+	s.listener = listener
+	s.mutex.Unlock()
+
+	s.logger.Info("Control socket listening on %s", s.path)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			s.logger.Error("Control socket accept failed: %v", err)
+			continue
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Close stops accepting connections and removes the socket file.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+// removeStaleSocket deletes a socket left behind by a previous run. It never
+// removes anything that is not a socket, nor a socket another daemon is still
+// answering on.
+func (s *Server) removeStaleSocket() error {
+	info, err := os.Lstat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", s.path)
+	}
+
+	if conn, err := net.DialTimeout("unix", s.path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is already in use", s.path)
+	}
+
+	return os.Remove(s.path)
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		s.writeResponse(conn, &Response{
+			Version: ProtocolVersion,
+			Error:   fmt.Sprintf("invalid request: %v", err),
+			Code:    CodeProtocol,
+		})
+		return
+	}
+
+	if req.Version == ProtocolVersion && req.Command == "tail" {
+		if tailReq, err := process.ParseTailArgs(req.Args); err == nil && tailReq.Follow {
+			s.followTail(conn, tailReq)
+			return
+		}
+	}
+
+	s.writeResponse(conn, s.dispatch(&req))
+}
+
+func (s *Server) writeResponse(conn net.Conn, resp *Response) {
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.logger.Error("Failed to write control response: %v", err)
+	}
+}
+
+func (s *Server) dispatch(req *Request) *Response {
+	resp := &Response{Version: ProtocolVersion}
+
+	if req.Version != ProtocolVersion {
+		resp.Error = fmt.Sprintf("unsupported protocol version %d (server speaks %d)", req.Version, ProtocolVersion)
+		resp.Code = CodeProtocol
+		return resp
+	}
+
+	switch req.Command {
+	case "status":
+		s.handleStatus(req.Args, resp)
+	case "start":
+		s.forEachTarget(req.Args, resp, s.manager.StartTarget)
+	case "stop":
+		s.forEachTarget(req.Args, resp, s.manager.StopTarget)
+	case "restart":
+		s.forEachTarget(req.Args, resp, s.manager.RestartTarget)
+	case "signal":
+		s.handleSignal(req.Args, resp)
+	case "scale":
+		s.handleScale(req.Args, resp)
+	case "reload":
+		s.handleReload(req.Args, resp)
+	case "tail":
+		s.handleTail(req.Args, resp)
+	case "clear":
+		s.handleClear(req.Args, resp)
+	default:
+		resp.Error = fmt.Sprintf("unknown command: %s", req.Command)
+	}
+
+	resp.OK = resp.Error == ""
+	for _, result := range resp.Results {
+		if !result.OK {
+			resp.OK = false
+		}
+	}
+	return resp
+}
+
+func (s *Server) handleStatus(programs []string, resp *Response) {
+	status := s.manager.Snapshot()
+	if len(programs) == 0 {
+		resp.Status = status
+		return
+	}
+
+	known := make(map[string]bool)
+	for _, name := range s.manager.ProgramNames() {
+		known[name] = true
+	}
+
+	for _, name := range programs {
+		if !known[name] {
+			resp.Results = append(resp.Results, Result{Target: name, Error: "program not found in configuration"})
+			continue
+		}
+		for _, info := range status {
+			if info.Program == name {
+				resp.Status = append(resp.Status, info)
+			}
+		}
+	}
+}
+
+func (s *Server) forEachTarget(targets []string, resp *Response, action func(string) error) {
+	if len(targets) == 0 {
+		resp.Error = "at least one program or instance name is required"
+		return
+	}
+
+	for _, target := range targets {
+		result := Result{Target: target, OK: true}
+		if err := action(target); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+}
+
+func (s *Server) handleSignal(args []string, resp *Response) {
+	if len(args) < 2 {
+		resp.Error = "signal requires a signal name and at least one target"
+		return
+	}
+
+	signalName := strings.TrimPrefix(strings.ToUpper(args[0]), "SIG")
+	s.forEachTarget(args[1:], resp, func(target string) error {
+		return s.manager.SignalTarget(target, signalName)
+	})
+}
+
+func (s *Server) handleScale(args []string, resp *Response) {
+	if len(args) != 2 {
+		resp.Error = "scale requires a program name and a number of processes"
+		return
+	}
+
+	numProcs, err := strconv.Atoi(args[1])
+	if err != nil {
+		resp.Error = fmt.Sprintf("invalid number of processes: %s", args[1])
+		return
+	}
+
+	result := Result{Target: args[0], OK: true}
+	if err := s.manager.ScaleProgram(args[0], numProcs); err != nil {
+		result.OK = false
+		result.Error = err.Error()
+	}
+	resp.Results = append(resp.Results, result)
+}
+
+func (s *Server) handleReload(args []string, resp *Response) {
+	dryRun := false
+	for _, arg := range args {
+		if arg != DryRunFlag {
+			resp.Error = fmt.Sprintf("unknown reload option: %s", arg)
+			return
+		}
+		dryRun = true
+	}
+
+	if s.configFile == "" {
+		resp.Error = "no configuration file specified"
+		return
+	}
+
+	reload := s.manager.ReloadConfig
+	if dryRun {
+		reload = s.manager.PlanReload
+	}
+	report, err := reload(s.configFile)
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+
+	resp.Reload = report
+	for _, program := range report.Programs {
+		if program.Error != "" {
+			resp.Results = append(resp.Results, Result{Target: program.Program, Error: program.Error})
+		}
+	}
+	if report.RolledBack {
+		resp.Error = fmt.Sprintf("reload rolled back: %s", report.RollbackReason)
+	}
+}
+
+func (s *Server) handleClear(programs []string, resp *Response) {
+	if len(programs) == 0 {
+		s.manager.CleanupDeadProcesses()
+		return
+	}
+
+	for _, name := range programs {
+		if !s.manager.IsProgram(name) {
+			resp.Results = append(resp.Results, Result{Target: name, Error: "program not found in configuration"})
+			continue
+		}
+		s.manager.CleanupProgram(name)
+		resp.Results = append(resp.Results, Result{Target: name, OK: true})
+	}
+}
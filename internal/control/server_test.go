@@ -0,0 +1,111 @@
+package control
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemoveStaleSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, path string)
+		wantErr string
+		removed bool
+	}{
+		{
+			name:    "missing",
+			setup:   func(t *testing.T, path string) {},
+			removed: true,
+		},
+		{
+			name: "stale socket",
+			setup: func(t *testing.T, path string) {
+				listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				listener.SetUnlinkOnClose(false)
+				listener.Close()
+			},
+			removed: true,
+		},
+		{
+			name: "live socket",
+			setup: func(t *testing.T, path string) {
+				listener, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { listener.Close() })
+			},
+			wantErr: "is already in use",
+		},
+		{
+			name: "regular file",
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "exists and is not a socket",
+		},
+		{
+			name: "symlink to a socket",
+			setup: func(t *testing.T, path string) {
+				target := filepath.Join(filepath.Dir(path), "target.sock")
+				listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: target, Net: "unix"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				listener.SetUnlinkOnClose(false)
+				listener.Close()
+				if err := os.Symlink(target, path); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "exists and is not a socket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "taskmaster.sock")
+			tt.setup(t, path)
+
+			err := (&Server{path: path}).removeStaleSocket()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("removeStaleSocket() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("removeStaleSocket() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+
+			_, statErr := os.Lstat(path)
+			if removed := os.IsNotExist(statErr); removed != tt.removed {
+				t.Errorf("path removed = %v, want %v", removed, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDefaultSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	want := "/run/user/1000/taskmaster.sock"
+	if os.Geteuid() == 0 {
+		want = "/run/taskmaster.sock"
+	}
+	if got := DefaultSocketPath(); got != want {
+		t.Errorf("DefaultSocketPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if got := DefaultSocketPath(); os.Geteuid() != 0 && got != "" {
+		t.Errorf("DefaultSocketPath() without XDG_RUNTIME_DIR = %q, want it disabled", got)
+	}
+	if got := DefaultSocketPath(); strings.HasPrefix(got, os.TempDir()) {
+		t.Errorf("DefaultSocketPath() = %q, want a path outside %s", got, os.TempDir())
+	}
+}
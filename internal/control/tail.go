@@ -0,0 +1,107 @@
+package control
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+
+	"taskmaster/internal/process"
+)
+
+// followBatchWindow groups lines that arrive close together into one response.
+const followBatchWindow = 100 * time.Millisecond
+
+func (s *Server) handleTail(args []string, resp *Response) {
+	req, err := process.ParseTailArgs(args)
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+
+	lines, err := s.manager.Tail(req.Target, req.Stream, req.Lines)
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+	resp.Output = lines
+}
+
+// followTail answers with the buffered lines and then streams new output until
+// the client closes the connection.
+func (s *Server) followTail(conn net.Conn, req process.TailRequest) {
+	// Subscribe before reading the buffer so no line falls between both steps
+	follow, stop, err := s.manager.FollowOutput(req.Target, req.Stream)
+	if err != nil {
+		s.writeResponse(conn, &Response{Version: ProtocolVersion, Error: err.Error()})
+		return
+	}
+	defer stop()
+
+	lines, err := s.manager.Tail(req.Target, req.Stream, req.Lines)
+	if err != nil {
+		s.writeResponse(conn, &Response{Version: ProtocolVersion, Error: err.Error()})
+		return
+	}
+
+	encoder := json.NewEncoder(conn)
+	send := func(lines []process.OutputLine) bool {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return encoder.Encode(&Response{Version: ProtocolVersion, OK: true, Output: lines}) == nil
+	}
+	if !send(lines) {
+		return
+	}
+
+	// Lines published between subscribing and reading the buffer arrive twice
+	cursor := make(process.OutputCursor)
+	for _, line := range lines {
+		cursor.Advance(line)
+	}
+
+	// The client never sends anything else: a finished read means it went away
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Time{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	for {
+		var batch []process.OutputLine
+		select {
+		case <-closed:
+			return
+		case line, ok := <-follow:
+			if !ok {
+				return
+			}
+			batch = append(batch, line)
+		}
+
+		timer := time.NewTimer(followBatchWindow)
+	collect:
+		for {
+			select {
+			case line, ok := <-follow:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, line)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		var fresh []process.OutputLine
+		for _, line := range batch {
+			if cursor.Advance(line) {
+				fresh = append(fresh, line)
+			}
+		}
+		if len(fresh) > 0 && !send(fresh) {
+			return
+		}
+	}
+}
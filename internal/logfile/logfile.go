@@ -0,0 +1,233 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Options indica cuándo y cómo se rota un File
+type Options struct {
+	MaxBytes int64         // rotar si el fichero superaría este tamaño (0 = nunca)
+	Interval time.Duration // rotar cuando el fichero lleve este tiempo abierto (0 = nunca)
+	Backups  int           // ficheros rotados que se conservan (path.1 ... path.N)
+	Compress bool          // comprimir con gzip los ficheros rotados (path.1.gz ...)
+}
+
+// File es un fichero de log de sólo añadir que se rota por tamaño o antigüedad;
+// admite uso concurrente, así que varios escritores pueden compartir la ruta
+type File struct {
+	mutex  sync.Mutex
+	path   string
+	opts   Options
+	file   *os.File // nil tras una reapertura fallida; la siguiente escritura lo reintenta
+	size   int64
+	opened time.Time // inicio del periodo de rotación actual
+	closed bool
+
+	compressing chan struct{} // se cierra al terminar el gzip en segundo plano de path.1
+}
+
+// Open abre (o crea) el fichero de path para añadir al final
+func Open(path string, opts Options) (*File, error) {
+	f := &File{path: path, opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path devuelve la ruta en la que se escribe
+func (f *File) Path() string {
+	return f.path
+}
+
+// SetOptions cambia la rotación; se aplica desde la siguiente escritura
+func (f *File) SetOptions(opts Options) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.opts = opts
+}
+
+// Write añade p al fichero, rotándolo antes si p superaría MaxBytes o si el
+// fichero actual es más antiguo que Interval
+func (f *File) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("log file %s is closed", f.path)
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var rotateErr error
+	if f.needsRotation(len(p)) {
+		if rotateErr = f.rotate(); rotateErr != nil && f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil && rotateErr != nil {
+		// Los datos se escribieron en el fichero actual, pero la rotación falló
+		err = fmt.Errorf("failed to rotate %s: %w", f.path, rotateErr)
+	}
+	return n, err
+}
+
+// Reopen cierra el fichero y vuelve a abrir su ruta; herramientas como
+// logrotate lo mueven y después piden al escritor que lo reabra
+func (f *File) Reopen() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("log file %s is closed", f.path)
+	}
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Close cierra el fichero
+func (f *File) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.closed = true
+	f.waitCompression()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	return nil
+}
+
+func (f *File) needsRotation(pending int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.MaxBytes > 0 && f.size+int64(pending) > f.opts.MaxBytes {
+		return true
+	}
+	return f.opts.Interval > 0 && time.Since(f.opened) >= f.opts.Interval
+}
+
+// rotate desplaza path.N-1 -> path.N ... path -> path.1 y empieza un fichero
+// nuevo; sin copias configuradas sólo trunca el actual
+func (f *File) rotate() error {
+	f.file.Close()
+	f.file = nil
+
+	if f.opts.Backups <= 0 {
+		truncErr := os.Truncate(f.path, 0)
+		if err := f.open(); err != nil {
+			return err
+		}
+		return truncErr
+	}
+
+	// path.1 no puede desplazarse mientras se comprime
+	f.waitCompression()
+
+	os.Remove(f.backupName(f.opts.Backups, ""))
+	os.Remove(f.backupName(f.opts.Backups, ".gz"))
+	for i := f.opts.Backups - 1; i >= 1; i-- {
+		// Las copias de antes de cambiar la compresión conservan su extensión
+		for _, e := range []string{".gz", ""} {
+			if _, err := os.Stat(f.backupName(i, e)); err == nil {
+				os.Rename(f.backupName(i, e), f.backupName(i+1, e))
+			}
+		}
+	}
+
+	if err := os.Rename(f.path, f.backupName(1, "")); err != nil {
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if f.opts.Compress {
+		// Comprimir un fichero grande lleva tiempo: se hace en segundo plano
+		// para no bloquear a los escritores. Si falla, la copia se queda sin
+		// comprimir
+		done := make(chan struct{})
+		f.compressing = done
+		go func(path string) {
+			defer close(done)
+			compress(path)
+		}(f.backupName(1, ""))
+	}
+
+	return f.open()
+}
+
+// waitCompression espera a la compresión en segundo plano de la última
+// rotación, si la hay
+func (f *File) waitCompression() {
+	if f.compressing != nil {
+		<-f.compressing
+		f.compressing = nil
+	}
+}
+
+func (f *File) backupName(index int, ext string) string {
+	return fmt.Sprintf("%s.%d%s", f.path, index, ext)
+}
+
+// compress sustituye path por path.gz comprimido con gzip
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
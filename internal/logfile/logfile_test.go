@@ -0,0 +1,175 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLines escribe cada línea en f por separado, como lo haría un programa
+func writeLines(t *testing.T, f *File, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := f.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("Write(%q): %v", line, err)
+		}
+	}
+}
+
+// listDir devuelve los nombres de los ficheros de dir, ordenados
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotateBySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		lines   []string
+		files   []string
+		current string
+	}{
+		{
+			name:    "below the limit",
+			opts:    Options{MaxBytes: 100, Backups: 3},
+			lines:   []string{"one", "two"},
+			files:   []string{"app.log"},
+			current: "one\ntwo\n",
+		},
+		{
+			name:    "shifts backups",
+			opts:    Options{MaxBytes: 4, Backups: 3},
+			lines:   []string{"one", "two", "three"},
+			files:   []string{"app.log", "app.log.1", "app.log.2"},
+			current: "three\n",
+		},
+		{
+			name:    "keeps at most Backups files",
+			opts:    Options{MaxBytes: 4, Backups: 2},
+			lines:   []string{"l1", "l2", "l3", "l4", "l5"},
+			files:   []string{"app.log", "app.log.1", "app.log.2"},
+			current: "l5\n",
+		},
+		{
+			name:    "no backups truncates",
+			opts:    Options{MaxBytes: 4, Backups: 0},
+			lines:   []string{"one", "two"},
+			files:   []string{"app.log"},
+			current: "two\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			f, err := Open(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeLines(t, f, tt.lines...)
+			f.Close()
+
+			if got := listDir(t, dir); strings.Join(got, " ") != strings.Join(tt.files, " ") {
+				t.Errorf("files = %v, want %v", got, tt.files)
+			}
+			if got := readFile(t, path); got != tt.current {
+				t.Errorf("current file = %q, want %q", got, tt.current)
+			}
+		})
+	}
+}
+
+func TestRotateKeepsNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := Open(path, Options{MaxBytes: 4, Backups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, f, "l1", "l2", "l3", "l4")
+	f.Close()
+
+	for name, want := range map[string]string{"app.log": "l4\n", "app.log.1": "l3\n", "app.log.2": "l2\n"} {
+		if got := readFile(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRotateCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := Open(path, Options{MaxBytes: 4, Backups: 3, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, f, "l1", "l2", "l3")
+	// Close espera a la compresión en segundo plano
+	f.Close()
+
+	want := []string{"app.log", "app.log.1.gz", "app.log.2.gz"}
+	if got := listDir(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "app.log.2.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "l1\n" {
+		t.Errorf("app.log.2.gz = %q, want %q", data, "l1\n")
+	}
+}
+
+func TestRotateByInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := Open(path, Options{Interval: time.Millisecond, Backups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, f, "old")
+	time.Sleep(5 * time.Millisecond)
+	writeLines(t, f, "new")
+	f.Close()
+
+	if got := readFile(t, path); got != "new\n" {
+		t.Errorf("current file = %q, want %q", got, "new\n")
+	}
+	if got := readFile(t, path+".1"); got != "old\n" {
+		t.Errorf("backup = %q, want %q", got, "old\n")
+	}
+}
@@ -1,67 +1,264 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
+
+	"taskmaster/internal/logfile"
 )
 
+// LogBroadcaster receives every record that passes the minimum level.
 type LogBroadcaster interface {
-	BroadcastLog(level, message, program string)
+	BroadcastLog(record Record)
+}
+
+// Level is the severity of a log record.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+	LevelFatal: "FATAL",
+}
+
+func (l Level) String() string {
+	if name, exists := levelNames[l]; exists {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// ParseLevel converts debug, info, warn or error (any case) to a Level.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) || (level == LevelWarn && strings.EqualFold(name, "warning")) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %s (use debug, info, warn or error)", name)
+}
+
+// Format selects how records are written.
+type Format int
+
+const (
+	FormatText Format = iota // [timestamp] LEVEL: message
+	FormatJSON               // one JSON object per line
+)
+
+// ParseFormat converts text or json to a Format.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return FormatText, fmt.Errorf("unknown log format: %s (use text or json)", name)
+}
+
+// Fields are the structured attributes attached to a supervisor event.
+type Fields struct {
+	Event    string `json:"event,omitempty"`
+	Program  string `json:"program,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	PID      int    `json:"pid,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"` // nil when not applicable, so 0 is kept
+}
+
+// WithExitCode returns a copy of the fields carrying an exit code.
+func (f Fields) WithExitCode(code int) Fields {
+	f.ExitCode = &code
+	return f
+}
+
+// Record is a single log entry, as written in JSON mode and as broadcast.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Fields
 }
 
 type Logger struct {
-	file        *os.File
-	log         *log.Logger
+	mutex       sync.Mutex
+	file        *logfile.File
 	broadcaster LogBroadcaster
+	sinks       []Sink
+	console     bool
+	minLevel    Level
+	format      Format
 }
 
-func New(filename string) (*Logger, error) {
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// New opens the log file at filename, rotating it according to opts.
+func New(filename string, opts logfile.Options) (*Logger, error) {
+	file, err := logfile.Open(filename, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	logger := log.New(file, "", 0)
-
 	return &Logger{
-		file: file,
-		log:  logger,
+		file:     file,
+		console:  true,
+		minLevel: LevelInfo,
 	}, nil
 }
 
-func (l *Logger) logf(level string, format string, args ...interface{}) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
-	l.log.Printf("[%s] %s: %s", timestamp, level, message)
+// SetLevel sets the minimum level; records below it are discarded.
+func (l *Logger) SetLevel(level Level) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.minLevel = level
+}
+
+// SetConsole enables or disables the copy of every line on stdout, for
+// example when a JournalSink writes to stdout instead.
+func (l *Logger) SetConsole(enabled bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.console = enabled
+}
+
+// AddSink sends every record that passes the minimum level to sink too.
+func (l *Logger) AddSink(sink Sink) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// SetFormat switches between text and JSON lines output.
+func (l *Logger) SetFormat(format Format) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.format = format
+}
+
+func (l *Logger) log(level Level, fields Fields, format string, args ...interface{}) {
+	l.mutex.Lock()
+	if level < l.minLevel {
+		l.mutex.Unlock()
+		return
+	}
+
+	record := Record{
+		Time:    time.Now(),
+		Level:   level.String(),
+		Message: fmt.Sprintf(format, args...),
+		Fields:  fields,
+	}
+	line := l.formatRecord(record)
 
+	fmt.Fprintln(l.file, line)
 	// También mostrar en consola para debugging
-	fmt.Printf("[%s] %s: %s\n", timestamp, level, message)
+	if l.console {
+		fmt.Println(line)
+	}
+
+	broadcaster := l.broadcaster
+	sinks := l.sinks
+	l.mutex.Unlock()
+
+	// Sinks report their own failures, since logging them here could loop
+	for _, sink := range sinks {
+		sink.WriteRecord(record)
+	}
 
 	// Broadcast to WebSocket clients if broadcaster is available
-	if l.broadcaster != nil {
-		l.broadcaster.BroadcastLog(level, message, "taskmaster")
+	if broadcaster != nil {
+		broadcaster.BroadcastLog(record)
+	}
+}
+
+func (l *Logger) formatRecord(record Record) string {
+	if l.format == FormatJSON {
+		data, err := json.Marshal(record)
+		if err == nil {
+			return string(data)
+		}
 	}
+	return fmt.Sprintf("[%s] %s: %s", record.Time.Format("2006-01-02 15:04:05"), record.Level, record.Message)
+}
+
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.log(LevelDebug, Fields{}, format, args...)
 }
 
 func (l *Logger) Info(format string, args ...interface{}) {
-	l.logf("INFO", format, args...)
+	l.log(LevelInfo, Fields{}, format, args...)
+}
+
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.log(LevelWarn, Fields{}, format, args...)
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.logf("ERROR", format, args...)
+	l.log(LevelError, Fields{}, format, args...)
 }
 
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	l.logf("FATAL", format, args...)
+	l.log(LevelFatal, Fields{}, format, args...)
 	os.Exit(1)
 }
 
+// With returns an entry that attaches fields to every record it logs.
+func (l *Logger) With(fields Fields) *Entry {
+	return &Entry{logger: l, fields: fields}
+}
+
+// Reopen closes the log file and opens its path again, so that external
+// tools like logrotate can move it away.
+func (l *Logger) Reopen() error {
+	return l.file.Reopen()
+}
+
 func (l *Logger) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, sink := range l.sinks {
+		sink.Close()
+	}
+	l.sinks = nil
 	return l.file.Close()
 }
 
 func (l *Logger) SetBroadcaster(broadcaster LogBroadcaster) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.broadcaster = broadcaster
 }
+
+// Entry logs records carrying a fixed set of structured fields.
+type Entry struct {
+	logger *Logger
+	fields Fields
+}
+
+func (e *Entry) Debug(format string, args ...interface{}) {
+	e.logger.log(LevelDebug, e.fields, format, args...)
+}
+
+func (e *Entry) Info(format string, args ...interface{}) {
+	e.logger.log(LevelInfo, e.fields, format, args...)
+}
+
+func (e *Entry) Warn(format string, args ...interface{}) {
+	e.logger.log(LevelWarn, e.fields, format, args...)
+}
+
+func (e *Entry) Error(format string, args ...interface{}) {
+	e.logger.log(LevelError, e.fields, format, args...)
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmaster/internal/logfile"
+)
+
+// logLines logs through a JSON logger and returns the lines of its file.
+func logLines(t *testing.T, log func(l *Logger)) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taskmaster.log")
+	l, err := New(path, logfile.Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	l.SetConsole(false)
+	l.SetFormat(FormatJSON)
+	log(l)
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestJSONFormat(t *testing.T) {
+	before := time.Now().Add(-time.Second)
+	lines := logLines(t, func(l *Logger) {
+		fields := Fields{Event: "process_exited", Program: "web", Instance: "web_0", PID: 4242}
+		l.With(fields.WithExitCode(0)).Error("Process %s exited with code %d", "web_0", 0)
+		l.Info("Configuration reloaded successfully")
+	})
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), lines)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("line is not JSON: %v\n%s", err, lines[0])
+	}
+
+	var keys []string
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	wantKeys := []string{"event", "exit_code", "instance", "level", "message", "pid", "program", "time"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("keys = %v, want %v", keys, wantKeys)
+	}
+
+	want := map[string]interface{}{
+		"level":     "ERROR",
+		"message":   "Process web_0 exited with code 0",
+		"event":     "process_exited",
+		"program":   "web",
+		"instance":  "web_0",
+		"pid":       4242.0,
+		"exit_code": 0.0, // kept even though it is zero
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	stamp, _ := record["time"].(string)
+	when, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		t.Errorf("time %q is not RFC 3339: %v", stamp, err)
+	} else if when.Before(before) || when.After(time.Now()) {
+		t.Errorf("time %s is not the logging time", when)
+	}
+
+	// Records without fields carry only time, level and message
+	var plain map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &plain); err != nil {
+		t.Fatalf("line is not JSON: %v\n%s", err, lines[1])
+	}
+	if len(plain) != 3 || plain["level"] != "INFO" || plain["message"] != "Configuration reloaded successfully" {
+		t.Errorf("record without fields = %v, want only time, level and message", plain)
+	}
+}
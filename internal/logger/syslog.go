@@ -0,0 +1,264 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Facility is a syslog facility (RFC 5424 section 6.2.1).
+type Facility int
+
+var facilityNames = map[string]Facility{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// ParseFacility converts a facility name such as daemon or local0 to a Facility.
+func ParseFacility(name string) (Facility, error) {
+	if facility, exists := facilityNames[strings.ToLower(name)]; exists {
+		return facility, nil
+	}
+	return 0, fmt.Errorf("unknown syslog facility: %s", name)
+}
+
+// sdID identifies taskmaster's structured data element. 32473 is the private
+// enterprise number reserved for documentation (RFC 5612).
+const sdID = "taskmaster@32473"
+
+// SyslogMessage is a message in RFC 5424 terms.
+type SyslogMessage struct {
+	Severity Severity
+	Time     time.Time
+	AppName  string            // the tag; the program name for child output
+	ProcID   string            // "" for none
+	MsgID    string            // "" for none
+	Params   map[string]string // structured data, written in key order
+	Text     string
+}
+
+// SyslogSink sends records as RFC 5424 messages to a syslog daemon over a
+// Unix socket (datagram or stream) or UDP.
+type SyslogSink struct {
+	mutex    sync.Mutex
+	network  string
+	address  string
+	facility Facility
+	appName  string
+	hostname string
+	conn     net.Conn
+	stream   bool
+	failing  bool
+}
+
+// NewSyslogSink connects to a syslog daemon. target is unix:///path (such as
+// unix:///dev/log) or udp://host:port; a bare path means unix.
+func NewSyslogSink(target string, facility Facility, appName string) (*SyslogSink, error) {
+	network, address, err := parseSyslogTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseSyslogTarget(target string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(target, "unix://"):
+		return "unix", strings.TrimPrefix(target, "unix://"), nil
+	case strings.HasPrefix(target, "udp://"):
+		return "udp", strings.TrimPrefix(target, "udp://"), nil
+	case strings.HasPrefix(target, "/"):
+		return "unix", target, nil
+	}
+	return "", "", fmt.Errorf("invalid syslog address %q (use unix:///path or udp://host:port)", target)
+}
+
+// connect dials the daemon. Unix sockets are tried as datagram first, like
+// /dev/log, and then as stream.
+func (s *SyslogSink) connect() error {
+	if s.network == "udp" {
+		conn, err := net.Dial("udp", s.address)
+		if err != nil {
+			return fmt.Errorf("cannot connect to syslog at udp://%s: %w", s.address, err)
+		}
+		s.conn, s.stream = conn, false
+		return nil
+	}
+
+	conn, err := net.Dial("unixgram", s.address)
+	if err == nil {
+		s.conn, s.stream = conn, false
+		return nil
+	}
+	conn, err = net.Dial("unix", s.address)
+	if err != nil {
+		return fmt.Errorf("cannot connect to syslog at unix://%s: %w", s.address, err)
+	}
+	s.conn, s.stream = conn, true
+	return nil
+}
+
+// WriteRecord sends a supervisor log record with its fields as structured data.
+func (s *SyslogSink) WriteRecord(record Record) error {
+	params := make(map[string]string)
+	if record.Program != "" {
+		params["program"] = record.Program
+	}
+	if record.Instance != "" {
+		params["instance"] = record.Instance
+	}
+	if record.PID != 0 {
+		params["pid"] = strconv.Itoa(record.PID)
+	}
+	if record.ExitCode != nil {
+		params["exit_code"] = strconv.Itoa(*record.ExitCode)
+	}
+
+	return s.Send(SyslogMessage{
+		Severity: severityOf(record.Level),
+		Time:     record.Time,
+		AppName:  s.appName,
+		ProcID:   strconv.Itoa(os.Getpid()),
+		MsgID:    record.Event,
+		Params:   params,
+		Text:     record.Message,
+	})
+}
+
+// Send formats and sends one message, reconnecting once if the socket broke
+// (for example because the syslog daemon restarted). The first failure is
+// reported on the standard logger, not through the Logger, to avoid a loop.
+func (s *SyslogSink) Send(msg SyslogMessage) error {
+	data := s.format(msg)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.write(data)
+	if err != nil {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		if err = s.connect(); err == nil {
+			err = s.write(data)
+		}
+	}
+
+	if err != nil && !s.failing {
+		log.Printf("Failed to send to syslog: %v", err)
+	}
+	s.failing = err != nil
+	return err
+}
+
+func (s *SyslogSink) write(data []byte) error {
+	if s.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	if s.stream {
+		data = append(data, '\n')
+	}
+	_, err := s.conn.Write(data)
+	return err
+}
+
+// format builds <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG.
+func (s *SyslogSink) format(msg SyslogMessage) []byte {
+	pri := int(s.facility)*8 + int(msg.Severity)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s %s",
+		pri,
+		msg.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(s.hostname, 255),
+		headerField(msg.AppName, 48),
+		headerField(msg.ProcID, 128),
+		headerField(msg.MsgID, 32),
+		structuredData(msg.Params))
+	if msg.Text != "" {
+		b.WriteByte(' ')
+		b.WriteString(msg.Text)
+	}
+	return []byte(b.String())
+}
+
+// headerField returns value limited to printable ASCII without spaces and
+// to max characters, or the nil value "-".
+func headerField(value string, max int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if b.Len() >= max {
+			break
+		}
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// structuredData returns the taskmaster SD element, or "-" when empty.
+func structuredData(params map[string]string) string {
+	if len(params) == 0 {
+		return "-"
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("[" + sdID)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=\"%s\"", key, sdEscaper.Replace(params[key]))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdEscaper escapes the characters RFC 5424 reserves in PARAM-VALUE.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func (s *SyslogSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
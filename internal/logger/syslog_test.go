@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenSyslog starts a local datagram listener playing the syslog daemon.
+func listenSyslog(t *testing.T, network string) (net.PacketConn, string) {
+	t.Helper()
+
+	var address, target string
+	switch network {
+	case "unixgram":
+		address = filepath.Join(t.TempDir(), "log")
+		target = "unix://" + address
+	case "udp":
+		address = "127.0.0.1:0"
+	}
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		t.Fatalf("listen %s: %v", network, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if network == "udp" {
+		target = "udp://" + conn.LocalAddr().String()
+	}
+	return conn, target
+}
+
+// readSyslog returns the next datagram received by conn.
+func readSyslog(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read syslog message: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogSinkSend(t *testing.T) {
+	when := time.Date(2024, 3, 9, 14, 5, 6, 123456000, time.UTC)
+
+	for _, network := range []string{"unixgram", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			conn, target := listenSyslog(t, network)
+
+			sink, err := NewSyslogSink(target, 16, "taskmaster")
+			if err != nil {
+				t.Fatalf("NewSyslogSink(%q): %v", target, err)
+			}
+			defer sink.Close()
+			sink.hostname = "host"
+
+			err = sink.Send(SyslogMessage{
+				Severity: SeverityNotice,
+				Time:     when,
+				AppName:  "web",
+				MsgID:    "stderr",
+				Params:   map[string]string{"instance": "web_0"},
+				Text:     "listening on :8080",
+			})
+			if err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			// local0 (16) * 8 + notice (5) = 133
+			want := `<133>1 2024-03-09T14:05:06.123456Z host web - stderr [taskmaster@32473 instance="web_0"] listening on :8080`
+			if got := readSyslog(t, conn); got != want {
+				t.Errorf("received\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSyslogSinkWriteRecord(t *testing.T) {
+	conn, target := listenSyslog(t, "unixgram")
+
+	sink, err := NewSyslogSink(target, 3, "taskmaster")
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	defer sink.Close()
+
+	err = sink.WriteRecord(Record{
+		Time:    time.Now(),
+		Level:   "ERROR",
+		Message: "process exited",
+		Fields:  Fields{Event: "exited", Program: "web", Instance: "web_1", PID: 4242}.WithExitCode(2),
+	})
+	if err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+
+	got := readSyslog(t, conn)
+	fields := strings.SplitN(got, " ", 7)
+	if len(fields) < 7 {
+		t.Fatalf("malformed message %q", got)
+	}
+
+	// daemon (3) * 8 + error (3) = 27
+	if fields[0] != "<27>1" {
+		t.Errorf("PRI and version = %q, want <27>1", fields[0])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+		t.Errorf("timestamp %q is not RFC 3339: %v", fields[1], err)
+	}
+	if fields[3] != "taskmaster" {
+		t.Errorf("APP-NAME = %q, want taskmaster", fields[3])
+	}
+	if fields[4] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("PROCID = %q, want %d", fields[4], os.Getpid())
+	}
+	if fields[5] != "exited" {
+		t.Errorf("MSGID = %q, want exited", fields[5])
+	}
+	wantSD := `[taskmaster@32473 exit_code="2" instance="web_1" pid="4242" program="web"] process exited`
+	if fields[6] != wantSD {
+		t.Errorf("SD and MSG = %q, want %q", fields[6], wantSD)
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	when := time.Date(2024, 3, 9, 14, 5, 6, 0, time.FixedZone("", 3600))
+
+	tests := []struct {
+		name     string
+		facility Facility
+		msg      SyslogMessage
+		want     string
+	}{
+		{
+			name:     "nil values",
+			facility: 3,
+			msg:      SyslogMessage{Severity: SeverityInfo, Time: when},
+			want:     "<30>1 2024-03-09T14:05:06.000000+01:00 host - - - -",
+		},
+		{
+			name:     "kern emergency",
+			facility: 0,
+			msg:      SyslogMessage{Severity: SeverityEmergency, Time: when, AppName: "a", Text: "x"},
+			want:     "<0>1 2024-03-09T14:05:06.000000+01:00 host a - - - x",
+		},
+		{
+			name:     "local7 debug",
+			facility: 23,
+			msg:      SyslogMessage{Severity: SeverityDebug, Time: when, AppName: "a", ProcID: "12", MsgID: "m"},
+			want:     "<191>1 2024-03-09T14:05:06.000000+01:00 host a 12 m -",
+		},
+		{
+			name:     "header fields are sanitized",
+			facility: 1,
+			msg:      SyslogMessage{Severity: SeverityWarning, Time: when, AppName: "my app\tñ"},
+			want:     "<12>1 2024-03-09T14:05:06.000000+01:00 host my_app__ - - -",
+		},
+		{
+			name:     "app name is truncated",
+			facility: 1,
+			msg:      SyslogMessage{Severity: SeverityInfo, Time: when, AppName: strings.Repeat("a", 60)},
+			want:     "<14>1 2024-03-09T14:05:06.000000+01:00 host " + strings.Repeat("a", 48) + " - - -",
+		},
+		{
+			name:     "structured data in key order and escaped",
+			facility: 3,
+			msg: SyslogMessage{
+				Severity: SeverityInfo,
+				Time:     when,
+				Params:   map[string]string{"z": "1", "a": `q"b\c]d`},
+				Text:     "hello world",
+			},
+			want: `<30>1 2024-03-09T14:05:06.000000+01:00 host - - - [taskmaster@32473 a="q\"b\\c\]d" z="1"] hello world`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SyslogSink{facility: tt.facility, hostname: "host"}
+			if got := string(s.format(tt.msg)); got != tt.want {
+				t.Errorf("format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSyslogTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		network string
+		address string
+		wantErr bool
+	}{
+		{"unix:///dev/log", "unix", "/dev/log", false},
+		{"/dev/log", "unix", "/dev/log", false},
+		{"udp://127.0.0.1:514", "udp", "127.0.0.1:514", false},
+		{"tcp://127.0.0.1:514", "", "", true},
+		{"dev/log", "", "", true},
+	}
+
+	for _, tt := range tests {
+		network, address, err := parseSyslogTarget(tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSyslogTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			continue
+		}
+		if network != tt.network || address != tt.address {
+			t.Errorf("parseSyslogTarget(%q) = %q, %q, want %q, %q", tt.target, network, address, tt.network, tt.address)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package process
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// backoffDelay calcula la espera antes del reintento attempt (empezando en 1):
+// crece exponencialmente desde BackoffInitial, se limita a BackoffMax y se
+// desplaza aleatoriamente un ±BackoffJitter para no reiniciar todo a la vez
+func backoffDelay(cfg *ProcessConfig, attempt int) time.Duration {
+	maxDelay := float64(cfg.BackoffMax)
+	delay := float64(cfg.BackoffInitial) * math.Pow(cfg.BackoffMultiplier, float64(attempt-1))
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if cfg.BackoffJitter > 0 {
+		delay += delay * cfg.BackoffJitter * (2*rand.Float64() - 1)
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
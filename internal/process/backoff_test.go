@@ -0,0 +1,47 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	cfg := &ProcessConfig{BackoffInitial: 1, BackoffMax: 10, BackoffMultiplier: 2}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // limitado a backoff_max
+		{20, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(cfg, tt.attempt); got != tt.want {
+			t.Errorf("backoffDelay(attempt %d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ProcessConfig
+		attempt  int
+		min, max time.Duration
+	}{
+		{"within jitter", ProcessConfig{BackoffInitial: 4, BackoffMax: 60, BackoffMultiplier: 2, BackoffJitter: 0.25}, 1, 3 * time.Second, 5 * time.Second},
+		{"never above max", ProcessConfig{BackoffInitial: 10, BackoffMax: 10, BackoffMultiplier: 2, BackoffJitter: 0.5}, 3, 5 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := backoffDelay(&tt.cfg, tt.attempt); got < tt.min || got > tt.max {
+					t.Fatalf("backoffDelay() = %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
@@ -2,113 +2,203 @@ package process
 
 import (
 	"fmt"
+	"sort"
 	"taskmaster/internal/config"
 )
 
-// applyConfigChanges aplica los cambios de configuración
-func (m *Manager) applyConfigChanges(newConfig *config.Config) error {
-	oldPrograms := make(map[string]config.Program)
-	for name, program := range m.config.Programs {
-		oldPrograms[name] = program
+// planReload calcula qué se haría con cada programa al pasar a la nueva
+// configuración, sin tocar ningún proceso (asume el lock)
+func (m *Manager) planReload(newConfig *config.Config) *ReloadReport {
+	report := &ReloadReport{Programs: []ProgramReload{}}
+
+	// Programas nuevos y modificados, en el orden en que se aplicarán
+	for _, name := range newConfig.StartOrder() {
+		newProgram := newConfig.Programs[name]
+		oldProgram, existed := m.config.Programs[name]
+
+		var planned ProgramReload
+		if existed {
+			planned = m.planModifiedProgram(name, oldProgram, newProgram)
+		} else {
+			planned = m.planNewProgram(name, newProgram)
+		}
+		if planned.Action != "" {
+			report.Programs = append(report.Programs, planned)
+		}
 	}
 
-	m.config = newConfig
-
-	// Procesar programas nuevos y modificados
-	for name, newProgram := range newConfig.Programs {
-		if err := m.handleProgramChange(name, newProgram, oldPrograms); err != nil {
-			m.logger.Error("Failed to handle program change %s: %v", name, err)
+	// Programas eliminados
+	var removed []string
+	for name := range m.config.Programs {
+		if _, exists := newConfig.Programs[name]; !exists {
+			removed = append(removed, name)
 		}
-		delete(oldPrograms, name)
 	}
-
-	// Detener programas eliminados
-	for name := range oldPrograms {
-		m.logger.Info("Removing program %s (no longer in configuration)", name)
-		if err := m.stopProgramUnsafe(name); err != nil {
-			m.logger.Error("Failed to stop removed program %s: %v", name, err)
+	sort.Strings(removed)
+	for _, name := range removed {
+		planned := ProgramReload{Program: name, Action: ActionRemoved, Detail: "not running"}
+		if _, count := m.HasActiveProcesses(name); count > 0 {
+			planned.Detail = fmt.Sprintf("stop %s", pluralInstances(count))
 		}
+		report.Programs = append(report.Programs, planned)
 	}
 
-	m.logger.Info("Configuration reloaded successfully")
-	return nil
+	return report
 }
 
-// handleProgramChange maneja los cambios en un programa específico
-func (m *Manager) handleProgramChange(name string, newProgram config.Program, oldPrograms map[string]config.Program) error {
-	oldProgram, existed := oldPrograms[name]
-
-	if !existed {
-		return m.handleNewProgram(name, newProgram)
+// planNewProgram describe la acción para un programa que no existía
+func (m *Manager) planNewProgram(name string, program config.Program) ProgramReload {
+	planned := ProgramReload{Program: name, Action: ActionAdded, Detail: "autostart disabled, not started"}
+	if program.AutoStart {
+		planned.Detail = fmt.Sprintf("start %s", pluralInstances(program.NumProcs))
 	}
-
-	return m.handleModifiedProgram(name, oldProgram, newProgram)
+	return planned
 }
 
-// handleNewProgram maneja un programa nuevo
-func (m *Manager) handleNewProgram(name string, program config.Program) error {
-	if program.AutoStart {
-		m.logger.Info("Starting new program %s", name)
-		return m.startProgramUnsafe(name)
+// planModifiedProgram clasifica los cambios de un programa existente: reinicia
+// sólo si algún campo lo exige, escala si cambia numprocs y si no aplica en caliente
+func (m *Manager) planModifiedProgram(name string, oldProgram, newProgram config.Program) ProgramReload {
+	changes := config.DiffPrograms(oldProgram, newProgram)
+	planned := ProgramReload{Program: name, Changes: changes}
+	if len(changes) == 0 {
+		return planned
 	}
-	return nil
+
+	_, running := m.HasActiveProcesses(name)
+	switch {
+	case hasApply(changes, config.ApplyRestart):
+		planned.Action = ActionRestarted
+		switch {
+		case running > 0:
+			planned.Detail = fmt.Sprintf("restart %s", pluralInstances(running))
+		case newProgram.AutoStart:
+			planned.Detail = fmt.Sprintf("start %s", pluralInstances(newProgram.NumProcs))
+		default:
+			planned.Detail = "not running, not started"
+		}
+	case hasApply(changes, config.ApplyScale):
+		planned.Action = ActionScaled
+		if running > 0 {
+			planned.Detail = fmt.Sprintf("scale from %d to %d instances", oldProgram.NumProcs, newProgram.NumProcs)
+		} else {
+			planned.Detail = "not running, numprocs updated"
+		}
+	default:
+		planned.Action = ActionUpdated
+		planned.Detail = fmt.Sprintf("update %s in place", pluralInstances(len(m.processes[name])))
+	}
+	return planned
 }
 
-// handleModifiedProgram maneja un programa modificado
-func (m *Manager) handleModifiedProgram(name string, oldProgram, newProgram config.Program) error {
-	if !m.programsEqual(oldProgram, newProgram) {
-		m.logger.Info("Program %s configuration changed, restarting", name)
+// applyConfigChanges aplica los cambios de configuración y devuelve un informe
+// con lo que cambió en cada programa y la acción tomada (asume el lock)
+func (m *Manager) applyConfigChanges(newConfig *config.Config) *ReloadReport {
+	oldConfig := m.config
+	report := m.planReload(newConfig)
+	m.config = newConfig
 
-		if err := m.stopProgramUnsafe(name); err != nil {
-			return fmt.Errorf("failed to stop program for restart: %w", err)
+	for i := range report.Programs {
+		planned := &report.Programs[i]
+		if err := m.applyProgramChange(planned, oldConfig, newConfig); err != nil {
+			m.logger.Error("Failed to handle program change %s: %v", planned.Program, err)
+			planned.Error = err.Error()
 		}
+	}
 
+	for _, line := range report.Lines() {
+		m.logger.Info("Reload: %s", line)
+	}
+	return report
+}
+
+// applyProgramChange ejecuta la acción planificada para un programa
+func (m *Manager) applyProgramChange(planned *ProgramReload, oldConfig, newConfig *config.Config) error {
+	name := planned.Program
+	newProgram := newConfig.Programs[name]
+
+	switch planned.Action {
+	case ActionAdded:
 		if newProgram.AutoStart {
-			return m.startProgramUnsafe(name)
+			m.logger.Info("Starting new program %s", name)
+			return m.startProgramWhenReady(name)
+		}
+	case ActionRemoved:
+		m.logger.Info("Removing program %s (no longer in configuration)", name)
+		if active, _ := m.HasActiveProcesses(name); active {
+			return m.stopProgramUnsafe(name)
 		}
+	case ActionRestarted:
+		return m.restartModifiedProgram(name, newProgram)
+	case ActionScaled:
+		m.applyLiveChanges(name, newProgram)
+		return m.scaleProgramUnsafe(name, oldConfig.Programs[name].NumProcs, newProgram)
+	case ActionUpdated:
+		m.applyLiveChanges(name, newProgram)
 	}
 	return nil
 }
 
-// programsEqual compara dos configuraciones de programa
-func (m *Manager) programsEqual(old, new config.Program) bool {
-	return old.Cmd == new.Cmd &&
-		old.NumProcs == new.NumProcs &&
-		old.AutoRestart == new.AutoRestart &&
-		old.StopSignal == new.StopSignal &&
-		old.StopTime == new.StopTime &&
-		old.StartTime == new.StartTime &&
-		old.StartRetries == new.StartRetries &&
-		old.Stdout == new.Stdout &&
-		old.Stderr == new.Stderr &&
-		old.WorkingDir == new.WorkingDir &&
-		old.Umask == new.Umask &&
-		m.slicesEqual(old.ExitCodes, new.ExitCodes) &&
-		m.mapsEqual(old.Env, new.Env)
+// pluralInstances formatea un número de instancias
+func pluralInstances(n int) string {
+	if n == 1 {
+		return "1 instance"
+	}
+	return fmt.Sprintf("%d instances", n)
 }
 
-// slicesEqual compara dos slices de enteros
-func (m *Manager) slicesEqual(a, b []int) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i, v := range a {
-		if v != b[i] {
-			return false
+// restartModifiedProgram reinicia un programa cuya nueva configuración exige un proceso nuevo
+func (m *Manager) restartModifiedProgram(name string, newProgram config.Program) error {
+	m.logger.Info("Program %s configuration changed, restarting", name)
+
+	wasActive, _ := m.HasActiveProcesses(name)
+	if wasActive {
+		if err := m.stopProgramUnsafe(name); err != nil {
+			return fmt.Errorf("failed to stop program for restart: %w", err)
 		}
 	}
-	return true
+
+	// Las instancias muertas conservan la configuración anterior: se descartan
+	m.AutoCleanupProgram(name)
+
+	if wasActive || newProgram.AutoStart {
+		return m.startProgramWhenReady(name)
+	}
+	return nil
 }
 
-// mapsEqual compara dos mapas string-string
-func (m *Manager) mapsEqual(a, b map[string]string) bool {
-	if len(a) != len(b) {
-		return false
+// applyLiveChanges da a las instancias existentes una configuración nueva con
+// los campos que no requieren reiniciar el proceso. La configuración anterior
+// no se modifica: se sustituye el puntero de cada instancia (asume el lock).
+func (m *Manager) applyLiveChanges(name string, newProgram config.Program) {
+	replaced := make(map[*ProcessConfig]*ProcessConfig)
+	numProcs := newProgram.NumProcs
+
+	for _, instance := range m.processes[name] {
+		updated, exists := replaced[instance.Config]
+		if !exists {
+			// El cambio de numprocs lo aplica scaleProgramUnsafe
+			newProgram.NumProcs = instance.Config.NumProcs
+			updated = m.createProcessConfig(newProgram)
+			m.configureCgroup(name, updated)
+			m.outputs.configure(updated.Stdout, updated.StdoutLog)
+			m.outputs.configure(updated.Stderr, updated.StderrLog)
+			replaced[instance.Config] = updated
+		}
+		instance.Config = updated
+	}
+	newProgram.NumProcs = numProcs
+
+	if len(replaced) > 0 {
+		m.logger.Info("Applied live configuration changes to program %s", name)
 	}
-	for k, v := range a {
-		if b[k] != v {
-			return false
+}
+
+// hasApply indica si algún cambio se aplica de la forma indicada
+func hasApply(changes []config.FieldChange, apply string) bool {
+	for _, change := range changes {
+		if change.Apply == apply {
+			return true
 		}
 	}
-	return true
+	return false
 }
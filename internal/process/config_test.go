@@ -0,0 +1,51 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+
+	"taskmaster/internal/config"
+)
+
+func TestPlanReload(t *testing.T) {
+	program := func(cmd string, numProcs int, autoStart bool) config.Program {
+		return config.Program{Cmd: cmd, NumProcs: numProcs, AutoStart: autoStart, Priority: config.DefaultPriority}
+	}
+	oldConfig := &config.Config{Programs: map[string]config.Program{
+		"same":    program("sleep 1", 1, true),
+		"cmd":     program("sleep 1", 1, true),
+		"scale":   program("sleep 1", 1, true),
+		"live":    program("sleep 1", 1, true),
+		"removed": program("sleep 1", 1, true),
+	}}
+
+	live := program("sleep 1", 1, true)
+	live.StopTime = 30
+	newConfig := &config.Config{Programs: map[string]config.Program{
+		"same":   program("sleep 1", 1, true),
+		"cmd":    program("sleep 2", 1, true),
+		"scale":  program("sleep 1", 3, true),
+		"live":   live,
+		"added":  program("sleep 1", 2, true),
+		"manual": program("sleep 1", 1, false),
+	}}
+
+	m := NewManager(oldConfig, nil)
+	report := m.planReload(newConfig)
+
+	got := make(map[string][2]string)
+	for _, planned := range report.Programs {
+		got[planned.Program] = [2]string{planned.Action, planned.Detail}
+	}
+	want := map[string][2]string{
+		"cmd":     {ActionRestarted, "start 1 instance"},
+		"scale":   {ActionScaled, "not running, numprocs updated"},
+		"live":    {ActionUpdated, "update 0 instances in place"},
+		"added":   {ActionAdded, "start 2 instances"},
+		"manual":  {ActionAdded, "autostart disabled, not started"},
+		"removed": {ActionRemoved, "not running"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planReload() = %v, want %v", got, want)
+	}
+}
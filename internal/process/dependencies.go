@@ -0,0 +1,102 @@
+package process
+
+import (
+	"fmt"
+	"taskmaster/internal/config"
+	"time"
+)
+
+// readinessPollInterval es la frecuencia con la que se comprueba si un programa ya está RUNNING
+const readinessPollInterval = 100 * time.Millisecond
+
+// waitForDependencies espera a que todas las dependencias de un programa estén RUNNING
+func (m *Manager) waitForDependencies(name string) error {
+	m.mutex.RLock()
+	program, exists := m.config.Programs[name]
+	m.mutex.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	for _, dep := range program.DependsOn {
+		m.mutex.RLock()
+		depProgram := m.config.Programs[dep]
+		active, _ := m.HasActiveProcesses(dep)
+		m.mutex.RUnlock()
+
+		if !active {
+			return fmt.Errorf("dependency %s of %s is not running", dep, name)
+		}
+
+		m.logger.Debug("Waiting for dependency %s of %s to reach RUNNING", dep, name)
+		if err := m.waitForRunning(dep, startTimeout(depProgram)); err != nil {
+			return fmt.Errorf("dependency %s of %s: %w", dep, name, err)
+		}
+	}
+	return nil
+}
+
+// checkDependenciesActive verifica que las dependencias tengan instancias activas (asume el lock)
+func (m *Manager) checkDependenciesActive(name string, program config.Program) error {
+	for _, dep := range program.DependsOn {
+		if active, _ := m.HasActiveProcesses(dep); !active {
+			return fmt.Errorf("dependency %s of %s is not running", dep, name)
+		}
+	}
+	return nil
+}
+
+// waitForRunning espera a que todas las instancias de un programa lleven
+// RUNNING al menos starttime, o falla si alguna termina sin reinicio pendiente
+func (m *Manager) waitForRunning(name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ready, err := m.programReadiness(name)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("program %s did not reach RUNNING within %s", name, timeout)
+		}
+		time.Sleep(readinessPollInterval)
+	}
+}
+
+// programReadiness indica si un programa está completamente iniciado
+func (m *Manager) programReadiness(name string) (bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	instances := m.processes[name]
+	if len(instances) == 0 {
+		return false, fmt.Errorf("program %s is not running", name)
+	}
+
+	ready := true
+	for _, instance := range instances {
+		switch instance.State {
+		case StateRunning:
+			startTime := time.Duration(instance.Config.StartTime) * time.Second
+			if time.Since(instance.StartTime) < startTime {
+				ready = false
+			}
+		case StateStarting, StateRestarting, StateBackoff:
+			ready = false
+		default:
+			return false, fmt.Errorf("instance %s is %s", instance.Name, instance.State)
+		}
+	}
+	return ready, nil
+}
+
+// startTimeout calcula cuánto esperar a que un programa quede RUNNING
+// teniendo en cuenta sus reintentos y el backoff máximo entre ellos
+func startTimeout(program config.Program) time.Duration {
+	attempts := program.StartRetries + 1
+	seconds := (program.StartTime+1)*attempts + program.BackoffMax*program.StartRetries
+	return time.Duration(seconds)*time.Second + 5*time.Second
+}
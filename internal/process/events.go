@@ -0,0 +1,46 @@
+package process
+
+import "taskmaster/internal/logger"
+
+// Eventos de los registros estructurados, para que el pipeline de logs pueda
+// filtrarlos sin analizar el mensaje
+const (
+	EventProcessStarted     = "process_started"
+	EventProcessRunning     = "process_running"
+	EventProcessStartFailed = "process_start_failed"
+	EventProcessExited      = "process_exited"
+	EventProcessStopping    = "process_stopping"
+	EventProcessStopped     = "process_stopped"
+	EventProcessKilled      = "process_killed"
+	EventProcessBackoff     = "process_backoff"
+	EventProcessFatal       = "process_fatal"
+	EventProcessSignaled    = "process_signaled"
+	EventProcessOverLimit   = "process_over_limit"
+	EventProgramScaled      = "program_scaled"
+	EventConfigReloaded     = "config_reloaded"
+	EventConfigRolledBack   = "config_rolled_back"
+)
+
+// instanceLog devuelve un logger con los campos de una instancia y un evento
+func (m *Manager) instanceLog(instance *ProcessInstance, event string) *logger.Entry {
+	return m.logger.With(instanceFields(instance, event))
+}
+
+// instanceExitLog es como instanceLog pero incluye el código de salida
+func (m *Manager) instanceExitLog(instance *ProcessInstance, event string, exitCode int) *logger.Entry {
+	return m.logger.With(instanceFields(instance, event).WithExitCode(exitCode))
+}
+
+func instanceFields(instance *ProcessInstance, event string) logger.Fields {
+	return logger.Fields{
+		Event:    event,
+		Program:  instance.Program,
+		Instance: instance.Name,
+		PID:      instance.PID,
+	}
+}
+
+// programLog devuelve un logger con los campos de un programa y un evento
+func (m *Manager) programLog(program, event string) *logger.Entry {
+	return m.logger.With(logger.Fields{Event: event, Program: program})
+}
@@ -6,6 +6,10 @@ import (
 	"os/exec"
 	"strconv"
 	"syscall"
+	"taskmaster/internal/cgroup"
+	"taskmaster/internal/config"
+	"taskmaster/internal/logger"
+	"taskmaster/internal/procfs"
 	"taskmaster/pkg/signals"
 	"time"
 )
@@ -13,23 +17,58 @@ import (
 // startProcessInstance inicia una instancia específica de proceso
 func (m *Manager) startProcessInstance(instance *ProcessInstance, programName string) error {
 	instance.ManualStop = false
+	instance.limitRestart = false
+
+	// Descartar peticiones de parada que llegaron antes de este arranque
+	select {
+	case <-instance.StopChan:
+	default:
+	}
 
 	cmd, err := m.createCommand(instance)
 	if err != nil {
 		return fmt.Errorf("failed to create command: %w", err)
 	}
 
-	m.configureCommand(cmd, instance)
+	if err := m.configureCommand(cmd, instance); err != nil {
+		return err
+	}
+
+	gate, err := m.prepareLimits(cmd, instance)
+	if err != nil {
+		m.closeOutput(instance.output)
+		instance.output = nil
+		return err
+	}
 
 	if err := cmd.Start(); err != nil {
+		m.closeOutput(instance.output)
+		instance.output = nil
+		if gate != nil {
+			gate.abort()
+		}
 		return fmt.Errorf("failed to start command: %w", err)
 	}
 
+	// El proceso espera en la puerta hasta tener sus límites aplicados
+	if gate != nil {
+		if err := gate.release(m, instance, cmd.Process.Pid); err != nil {
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			cmd.Wait()
+			m.closeOutput(instance.output)
+			instance.output = nil
+			m.releaseCgroup(instance, instance.cgroup)
+			return err
+		}
+	}
+
 	instance.Cmd = cmd
 	instance.PID = cmd.Process.Pid
-	instance.State = StateRunning
+	instance.State = StateStarting
+	instance.StartTime = time.Now()
+	instance.exited = make(chan struct{})
 
-	go m.monitorProcess(instance, programName)
+	go m.monitorProcess(instance, programName, time.Duration(instance.Config.StartTime)*time.Second)
 
 	// Broadcast status update
 	m.broadcastStatus()
@@ -39,16 +78,19 @@ func (m *Manager) startProcessInstance(instance *ProcessInstance, programName st
 
 // createCommand crea el comando a ejecutar
 func (m *Manager) createCommand(instance *ProcessInstance) (*exec.Cmd, error) {
+	script := instance.Config.Cmd
 	if instance.Config.Umask != "" {
 		if err := m.validateUmask(instance.Config.Umask); err != nil {
-			m.logger.Error("Invalid umask format for %s: %s", instance.Name, instance.Config.Umask)
-			return exec.Command("sh", "-c", instance.Config.Cmd), nil
+			m.logger.Warn("Invalid umask format for %s: %s", instance.Name, instance.Config.Umask)
+		} else {
+			script = fmt.Sprintf("umask %s; exec %s", instance.Config.Umask, script)
 		}
-		wrappedCmd := fmt.Sprintf("umask %s; exec %s", instance.Config.Umask, instance.Config.Cmd)
-		return exec.Command("sh", "-c", wrappedCmd), nil
 	}
 
-	return exec.Command("sh", "-c", instance.Config.Cmd), nil
+	if m.needsLimitGate(instance.Config) {
+		script = limitGateScript + script
+	}
+	return exec.Command("sh", "-c", script), nil
 }
 
 // validateUmask valida el formato del umask
@@ -57,18 +99,33 @@ func (m *Manager) validateUmask(umask string) error {
 	return err
 }
 
-// configureCommand configura el comando con ambiente, directorio y redirecciones
-func (m *Manager) configureCommand(cmd *exec.Cmd, instance *ProcessInstance) {
-	m.configureEnvironment(cmd, instance.Config.Env)
+// configureCommand configura el comando con identidad, ambiente, directorio y redirecciones
+func (m *Manager) configureCommand(cmd *exec.Cmd, instance *ProcessInstance) error {
+	// Se resuelve en cada arranque para seguir los cambios en /etc/passwd
+	credential, err := config.ResolveCredential(instance.Config.User, instance.Config.Group, instance.Config.Groups)
+	if err != nil {
+		return fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+
+	m.configureEnvironment(cmd, instance.Config.Env, credential)
 	m.configureWorkingDir(cmd, instance.Config.WorkingDir)
-	m.configureRedirections(cmd, instance.Config.Stdout, instance.Config.Stderr)
-	m.configureProcessAttributes(cmd)
+	if err := m.configureRedirections(cmd, instance); err != nil {
+		return fmt.Errorf("failed to open output log: %w", err)
+	}
+	m.configureProcessAttributes(cmd, credential)
+	return nil
 }
 
-// configureEnvironment configura las variables de ambiente
-func (m *Manager) configureEnvironment(cmd *exec.Cmd, env map[string]string) {
+// configureEnvironment configura las variables de ambiente; al cambiar de
+// usuario HOME y USER pasan a ser las suyas, salvo que env las indique
+func (m *Manager) configureEnvironment(cmd *exec.Cmd, env map[string]string, credential *config.Credential) {
+	if credential != nil && credential.Username != "" {
+		cmd.Env = append(os.Environ(), "HOME="+credential.Home, "USER="+credential.Username)
+	}
 	if len(env) > 0 {
-		cmd.Env = os.Environ()
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
 		for key, value := range env {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 		}
@@ -82,68 +139,205 @@ func (m *Manager) configureWorkingDir(cmd *exec.Cmd, workingDir string) {
 	}
 }
 
-// configureRedirections configura las redirecciones de stdout y stderr
-func (m *Manager) configureRedirections(cmd *exec.Cmd, stdout, stderr string) {
-	if stdout != "" && stdout != "/dev/null" {
-		if file, err := os.OpenFile(stdout, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666); err == nil {
-			cmd.Stdout = file
-		}
+// configureProcessAttributes configura los atributos del proceso
+func (m *Manager) configureProcessAttributes(cmd *exec.Cmd, credential *config.Credential) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
 	}
-
-	if stderr != "" && stderr != "/dev/null" {
-		if file, err := os.OpenFile(stderr, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666); err == nil {
-			cmd.Stderr = file
+	if credential != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid:    credential.UID,
+			Gid:    credential.GID,
+			Groups: credential.Groups,
+			// Sin ser root setgroups(2) fallaría; la identidad es la misma
+			NoSetGroups: os.Geteuid() != 0,
 		}
 	}
 }
 
-// configureProcessAttributes configura los atributos del proceso
-func (m *Manager) configureProcessAttributes(cmd *exec.Cmd) {
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+// killProcessGroup envía SIGKILL a todo el grupo de procesos de la instancia;
+// con cgroup mata también a los descendientes que salieron del grupo
+func (m *Manager) killProcessGroup(instance *ProcessInstance) error {
+	return killGroup(instance.cgroup, instance.PID, instance.Cmd.Process)
+}
+
+// killGroup mata el cgroup de una ejecución o, sin él, su grupo de procesos;
+// no usa la instancia, así que sirve fuera del lock
+func killGroup(group *cgroup.Group, pid int, process *os.Process) error {
+	if group != nil {
+		if err := group.Kill(); err == nil {
+			return nil
+		}
+	}
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
+		return process.Kill()
 	}
+	return nil
 }
 
 // stopProcessInstance detiene una instancia específica de proceso
 func (m *Manager) stopProcessInstance(instance *ProcessInstance) bool {
+	// Una parada explícita cancela el reinicio que tuviera pendiente el watchdog
+	instance.limitRestart = false
+
+	// Una instancia en BACKOFF no tiene proceso: basta con cancelar el reinicio pendiente
+	if instance.State == StateBackoff {
+		instance.ManualStop = true
+		select {
+		case instance.StopChan <- true:
+		default:
+		}
+		instance.State = StateStopped
+		m.logger.Info("Cancelled pending restart of process %s", instance.Name)
+		m.broadcastStatus()
+		return true
+	}
+
 	if instance.Cmd == nil || instance.Cmd.Process == nil {
-		m.logger.Info("Process %s has no associated process to stop", instance.Name)
+		m.logger.Debug("Process %s has no associated process to stop", instance.Name)
 		return false
 	}
 
 	// Verificar si el proceso ya terminó
 	if instance.Cmd.ProcessState != nil {
-		m.logger.Info("Process %s already finished, no need to stop", instance.Name)
+		m.logger.Debug("Process %s already finished, no need to stop", instance.Name)
 		return false
 	}
 
 	// Verificar si el proceso aún existe enviando señal 0
 	if err := instance.Cmd.Process.Signal(syscall.Signal(0)); err != nil {
-		m.logger.Info("Process %s no longer exists (PID %d)", instance.Name, instance.PID)
+		m.logger.Debug("Process %s no longer exists (PID %d)", instance.Name, instance.PID)
 		return false
 	}
 
-	instance.ManualStop = true
+	m.instanceLog(instance, EventProcessStopping).Info("Stopping process %s with signal %s (timeout: %ds)",
+		instance.Name, instance.Config.StopSignal, instance.Config.StopTime)
+
+	stop := m.prepareStop(instance)
+	m.stopRun(stop, time.Duration(instance.Config.StopTime)*time.Second)
 
+	instance.State = StateStopped
+	
+	// Broadcast status update
+	m.broadcastStatus()
+	
+	return true
+}
+
+// runStop es lo que hace falta para detener una ejecución sin el lock
+type runStop struct {
+	name    string
+	fields  logger.Fields
+	process *os.Process
+	pid     int
+	group   *cgroup.Group
+	signal  string
+	exited  chan struct{}
+}
+
+// log devuelve un logger con los campos de la instancia y un evento
+func (s *runStop) log(m *Manager, event string) *logger.Entry {
+	fields := s.fields
+	fields.Event = event
+	return m.logger.With(fields)
+}
+
+// prepareStop marca la ejecución actual como parada a propósito, cancela el
+// reinicio pendiente y captura lo necesario para detenerla (asume el lock)
+func (m *Manager) prepareStop(instance *ProcessInstance) *runStop {
+	instance.ManualStop = true
 	select {
 	case instance.StopChan <- true:
 	default:
 	}
 
-	stopTimeout := time.Duration(instance.Config.StopTime) * time.Second
+	return &runStop{
+		name:    instance.Name,
+		fields:  instanceFields(instance, ""),
+		process: instance.Cmd.Process,
+		pid:     instance.PID,
+		group:   instance.cgroup,
+		signal:  instance.Config.StopSignal,
+		exited:  instance.exited,
+	}
+}
 
-	m.logger.Info("Stopping process %s with signal %s (timeout: %ds)",
-		instance.Name, instance.Config.StopSignal, instance.Config.StopTime)
+// stopRun envía la señal de parada a todo el grupo de procesos y espera hasta
+// timeout a que monitorProcess recoja al líder; si no termina se mata el grupo.
+// Lo que sobreviva al líder, como los hijos de un sh -c, también se mata.
+// Devuelve true si hubo que matar al líder con SIGKILL. No usa el lock ni la
+// instancia, así que sirve con el lock tomado y sin él
+func (m *Manager) stopRun(stop *runStop, timeout time.Duration) bool {
+	if timeout > 0 {
+		if err := signals.SendGroupSignal(stop.pid, stop.signal); err != nil {
+			m.logger.Error("Failed to send %s to process %s: %v", stop.signal, stop.name, err)
+		} else {
+			select {
+			case <-stop.exited:
+				m.killLeftovers(stop)
+				return false
+			case <-time.After(timeout):
+			}
+		}
+	}
 
-	if err := signals.GracefulStop(instance.Cmd.Process, instance.Config.StopSignal, stopTimeout); err != nil {
-		m.logger.Error("Failed to stop process %s gracefully: %v", instance.Name, err)
-		return false
+	stop.log(m, EventProcessKilled).Warn("Process %s did not stop in time, sending SIGKILL", stop.name)
+	if err := killGroup(stop.group, stop.pid, stop.process); err != nil && err != syscall.ESRCH {
+		m.logger.Error("Failed to kill process %s: %v", stop.name, err)
 	}
 
-	instance.State = StateStopped
-	
-	// Broadcast status update
-	m.broadcastStatus()
-	
+	select {
+	case <-stop.exited:
+	case <-time.After(outputWaitDelay + killWait):
+		m.logger.Error("Process %s (PID %d) still alive after SIGKILL", stop.name, stop.pid)
+	}
 	return true
 }
+
+// leftoverGrace es lo que se deja a los procesos del grupo que ya recibieron
+// la señal para terminar antes de tratarlos como supervivientes
+const leftoverGrace = 200 * time.Millisecond
+
+// killLeftovers mata los procesos del grupo que siguen vivos cuando el líder
+// ya terminó; con cgroup ya lo hizo releaseCgroup antes de cerrar exited
+func (m *Manager) killLeftovers(stop *runStop) {
+	if stop.group != nil {
+		return
+	}
+
+	deadline := time.Now().Add(leftoverGrace)
+	leftovers := liveGroupMembers(stop.pid)
+	for leftovers > 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		leftovers = liveGroupMembers(stop.pid)
+	}
+	if leftovers == 0 {
+		return
+	}
+
+	stop.log(m, EventProcessKilled).Warn("Killing %d leftover process(es) of %s in process group %d",
+		leftovers, stop.name, stop.pid)
+	if err := syscall.Kill(-stop.pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		m.logger.Error("Failed to kill leftover processes of %s: %v", stop.name, err)
+	}
+}
+
+// liveGroupMembers cuenta los procesos del grupo pgid que no son zombis. Sin
+// /proc sólo sabe si el grupo existe
+func liveGroupMembers(pgid int) int {
+	stats, err := procfs.ReadAllStats()
+	if err != nil {
+		if syscall.Kill(-pgid, 0) == nil {
+			return 1
+		}
+		return 0
+	}
+
+	count := 0
+	for _, stat := range stats {
+		if stat.PGRP == pgid && !stat.Zombie() {
+			count++
+		}
+	}
+	return count
+}
@@ -2,7 +2,10 @@ package process
 
 import (
 	"fmt"
+	"sort"
+	"taskmaster/internal/cgroup"
 	"taskmaster/internal/config"
+	"taskmaster/internal/logfile"
 	"time"
 )
 
@@ -13,20 +16,47 @@ func (m *Manager) startProgramUnsafe(name string) error {
 		return fmt.Errorf("program %s not found in configuration", name)
 	}
 
+	if err := m.checkDependenciesActive(name, program); err != nil {
+		return err
+	}
+
 	// Verificar procesos activos y limpiar si es necesario
-	if hasActive, activeCount := m.HasActiveProcesses(name); hasActive {
+	if _, activeCount := m.HasActiveProcesses(name); activeCount >= program.NumProcs {
 		return fmt.Errorf("program %s has %d active processes running", name, activeCount)
 	}
 
 	m.AutoCleanupProgram(name)
 
-	// Crear configuración de proceso
-	processConfig := m.createProcessConfig(program)
+	// Reutilizar la configuración de las instancias activas o crear una nueva
+	processConfig := m.programProcessConfig(name, program)
 
-	// Crear e iniciar procesos
+	// Crear e iniciar los procesos que falten
 	return m.createAndStartInstances(name, program.NumProcs, processConfig)
 }
 
+// startProgramWhenReady inicia un programa cuando sus dependencias llevan
+// starttime en RUNNING, como StartProgram. Suelta el lock mientras espera para
+// que las dependencias puedan avanzar (asume el lock)
+func (m *Manager) startProgramWhenReady(name string) error {
+	if program, exists := m.config.Programs[name]; exists && len(program.DependsOn) > 0 {
+		m.mutex.Unlock()
+		err := m.waitForDependencies(name)
+		m.mutex.Lock()
+		if err != nil {
+			return err
+		}
+	}
+	return m.startProgramUnsafe(name)
+}
+
+// programProcessConfig devuelve la configuración compartida por las instancias de un programa
+func (m *Manager) programProcessConfig(name string, program config.Program) *ProcessConfig {
+	if instances := m.processes[name]; len(instances) > 0 {
+		return instances[0].Config
+	}
+	return m.createProcessConfig(program)
+}
+
 // stopProgramUnsafe detiene un programa sin bloquear (asume que ya se tiene el lock)
 func (m *Manager) stopProgramUnsafe(name string) error {
 	instances, exists := m.processes[name]
@@ -65,31 +95,58 @@ func (m *Manager) createProcessConfig(program config.Program) *ProcessConfig {
 		Env:          program.Env,
 		WorkingDir:   program.WorkingDir,
 		Umask:        program.Umask,
+		Priority:     program.Priority,
+		User:         program.User,
+		Group:        program.Group,
+		Groups:       program.Groups,
+
+		BackoffInitial:    program.BackoffInitial,
+		BackoffMax:        program.BackoffMax,
+		BackoffMultiplier: program.BackoffMultiplier,
+		BackoffJitter:     program.BackoffJitter,
+
+		StdoutLog: logfile.Options{
+			MaxBytes: int64(program.StdoutLogfileMaxBytes),
+			Backups:  program.StdoutLogfileBackups,
+			Compress: program.StdoutLogfileCompress,
+		},
+		StderrLog: logfile.Options{
+			MaxBytes: int64(program.StderrLogfileMaxBytes),
+			Backups:  program.StderrLogfileBackups,
+			Compress: program.StderrLogfileCompress,
+		},
+
+		MaxRSS:        int64(program.MaxRSS),
+		MaxCPUPercent: program.MaxCPUPercent,
+		MaxCPUWindow:  program.MaxCPUWindow,
+		MaxOpenFiles:  program.MaxOpenFiles,
+
+		Rlimits: program.Rlimits,
+		Cgroup: cgroup.Limits{
+			MemoryMax: int64(program.MemoryMax),
+			CPUMax:    program.CPUMax,
+			PidsMax:   program.PidsMax,
+		},
 	}
 }
 
-// createAndStartInstances crea e inicia múltiples instancias de un proceso
+// createAndStartInstances crea e inicia las instancias de un proceso que no estén ya activas
 func (m *Manager) createAndStartInstances(name string, numProcs int, processConfig *ProcessConfig) error {
-	var errors []string
+	return m.createAndStartInstanceRange(name, 0, numProcs, processConfig)
+}
 
-	for i := 0; i < numProcs; i++ {
-		instance := &ProcessInstance{
-			Name:      fmt.Sprintf("%s_%d", name, i),
-			Config:    processConfig,
-			State:     StateStarting,
-			StartTime: time.Now(),
-			StopChan:  make(chan bool, 1),
-		}
+// createAndStartInstanceRange inicia las instancias con índice en [from, to) que no estén activas
+func (m *Manager) createAndStartInstanceRange(name string, from, to int, processConfig *ProcessConfig) error {
+	var errors []string
 
-		if err := m.startProcessInstance(instance, name); err != nil {
-			m.logger.Error("Failed to start process %s: %v", instance.Name, err)
-			instance.State = StateFailed
-			errors = append(errors, fmt.Sprintf("%s: %v", instance.Name, err))
+	for i := from; i < to; i++ {
+		if instance := m.findInstance(name, i); instance != nil && m.isActiveInstance(instance) {
 			continue
 		}
 
-		m.processes[name] = append(m.processes[name], instance)
-		m.logger.Info("Started process %s (PID: %d)", instance.Name, instance.PID)
+		if err := m.startNewInstance(name, i, processConfig); err != nil {
+			errors = append(errors, err.Error())
+		}
 	}
 
 	if len(errors) > 0 {
@@ -97,3 +154,32 @@ func (m *Manager) createAndStartInstances(name string, numProcs int, processConf
 	}
 	return nil
 }
+
+// startNewInstance crea la instancia index de un programa, la inicia y la registra
+func (m *Manager) startNewInstance(name string, index int, processConfig *ProcessConfig) error {
+	instance := &ProcessInstance{
+		Name:      instanceName(name, index),
+		Program:   name,
+		Index:     index,
+		Config:    processConfig,
+		State:     StateStarting,
+		StartTime: time.Now(),
+		StopChan:  make(chan bool, 1),
+	}
+
+	if err := m.startProcessInstance(instance, name); err != nil {
+		m.logger.Error("Failed to start process %s: %v", instance.Name, err)
+		instance.State = StateFailed
+		return fmt.Errorf("%s: %v", instance.Name, err)
+	}
+
+	m.removeInstance(name, index)
+	m.processes[name] = append(m.processes[name], instance)
+	sort.Slice(m.processes[name], func(i, j int) bool {
+		return m.processes[name][i].Index < m.processes[name][j].Index
+	})
+
+	m.instanceLog(instance, EventProcessStarted).Info("Started process %s (PID: %d), STARTING for %ds",
+		instance.Name, instance.PID, processConfig.StartTime)
+	return nil
+}
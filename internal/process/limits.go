@@ -0,0 +1,218 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"taskmaster/internal/cgroup"
+	"taskmaster/internal/config"
+)
+
+// limitGateScript hace que el shell espere, sin ejecutar nada del comando, a
+// que el supervisor aplique los rlimits y el cgroup: lee una línea del
+// descriptor 3 y lo cierra
+const limitGateScript = "read _ <&3 || exit 126; exec 3<&-; "
+
+// rlimitNproc es RLIMIT_NPROC, que el paquete syscall no exporta
+const rlimitNproc = 6
+
+// Reintentos para eliminar el cgroup de una instancia tras matar sus restos
+const (
+	cgroupRemoveAttempts = 20
+	cgroupRemoveDelay    = 50 * time.Millisecond
+)
+
+// SetCgroups activa los cgroups v2: cada programa tendrá su propio cgroup con
+// sus límites y cada instancia uno hoja dentro de él
+func (m *Manager) SetCgroups(hierarchy *cgroup.Hierarchy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cgroups = hierarchy
+	m.cgroupErrors = make(map[string]string)
+}
+
+// warnUnappliedCgroupLimits avisa de los programas que una recarga deja con
+// límites de cgroup nuevos cuando taskmaster corre sin cgroups: el subárbol
+// sólo se prepara al arrancar (asume el lock)
+func (m *Manager) warnUnappliedCgroupLimits(oldConfig, newConfig *config.Config) {
+	if m.cgroups != nil {
+		return
+	}
+	for _, name := range newConfig.CgroupLimited() {
+		// De los que ya tenían límites se avisó al arrancar o en otra recarga
+		if old, exists := oldConfig.Programs[name]; exists && old.HasCgroupLimits() {
+			continue
+		}
+		m.logger.Warn("⚠️  cgroup limits of program %s are not applied: taskmaster runs without cgroups, which are only set up at startup", name)
+	}
+}
+
+// needsLimitGate indica si el proceso debe esperar a que se le apliquen
+// límites tras el fork
+func (m *Manager) needsLimitGate(processConfig *ProcessConfig) bool {
+	return m.cgroups != nil || !processConfig.Rlimits.Empty()
+}
+
+// limitGate es la tubería por la que se libera al proceso una vez limitado
+type limitGate struct {
+	reader *os.File
+	writer *os.File
+}
+
+// prepareLimits actualiza el cgroup del programa y crea la tubería de la
+// puerta, que el hijo recibe como descriptor 3 (asume el lock)
+func (m *Manager) prepareLimits(cmd *exec.Cmd, instance *ProcessInstance) (*limitGate, error) {
+	instance.cgroup = nil
+	if !m.needsLimitGate(instance.Config) {
+		return nil, nil
+	}
+	m.configureCgroup(instance.Program, instance.Config)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create limit pipe: %w", err)
+	}
+	cmd.ExtraFiles = []*os.File{reader}
+	return &limitGate{reader: reader, writer: writer}, nil
+}
+
+// configureCgroup crea o actualiza el cgroup de un programa con sus límites,
+// avisando una sola vez de cada error distinto (asume el lock)
+func (m *Manager) configureCgroup(program string, processConfig *ProcessConfig) {
+	if m.cgroups == nil {
+		return
+	}
+
+	err := m.cgroups.ConfigureProgram(program, processConfig.Cgroup)
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	if message != "" && m.cgroupErrors[program] != message {
+		m.logger.Warn("⚠️  cgroup limits of program %s not fully applied: %v", program, err)
+	}
+	m.cgroupErrors[program] = message
+}
+
+// release mete el proceso en el cgroup de esta ejecución
+// (<programa>/<instancia>.<pid>), le aplica los rlimits y lo deja continuar.
+// Si falla, el proceso sigue bloqueado y hay que matarlo.
+func (gate *limitGate) release(m *Manager, instance *ProcessInstance, pid int) error {
+	gate.reader.Close()
+	defer gate.writer.Close()
+
+	if m.cgroups != nil {
+		if err := m.joinCgroup(instance, pid); err != nil {
+			m.logger.Warn("Failed to put process %s in its cgroup, running it without one: %v", instance.Name, err)
+		}
+	}
+
+	if err := setRlimits(pid, instance.Config.Rlimits); err != nil {
+		return err
+	}
+
+	_, err := gate.writer.Write([]byte("\n"))
+	return err
+}
+
+// joinCgroup crea el cgroup de una ejecución y mete en él al proceso (asume el lock)
+func (m *Manager) joinCgroup(instance *ProcessInstance, pid int) error {
+	group, err := m.cgroups.Instance(instance.Program, fmt.Sprintf("%s.%d", instance.Name, pid))
+	if err != nil {
+		return err
+	}
+	if err := group.Add(pid); err != nil {
+		group.Remove()
+		return err
+	}
+	instance.cgroup = group
+	return nil
+}
+
+// abort cierra la puerta cuando el proceso no llegó a arrancar
+func (gate *limitGate) abort() {
+	gate.reader.Close()
+	gate.writer.Close()
+}
+
+// setRlimits aplica los límites configurados a un proceso con prlimit(2);
+// el límite blando y el duro toman el mismo valor
+func setRlimits(pid int, limits config.Rlimits) error {
+	for _, limit := range []struct {
+		name     string
+		resource int
+		value    *config.Rlimit
+	}{
+		{"nofile", syscall.RLIMIT_NOFILE, limits.NoFile},
+		{"nproc", rlimitNproc, limits.NProc},
+		{"core", syscall.RLIMIT_CORE, limits.Core},
+		{"as", syscall.RLIMIT_AS, limits.AS},
+	} {
+		if limit.value == nil {
+			continue
+		}
+		rlimit := syscall.Rlimit{Cur: uint64(*limit.value), Max: uint64(*limit.value)}
+		if err := prlimit(pid, limit.resource, &rlimit); err != nil {
+			return fmt.Errorf("failed to set rlimit %s=%s: %w", limit.name, limit.value, err)
+		}
+	}
+	return nil
+}
+
+func prlimit(pid, resource int, limit *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64,
+		uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// releaseCgroup mata lo que quede en el cgroup de una ejecución cuyo proceso
+// terminó (descendientes que sobrevivieron al padre) y lo elimina
+func (m *Manager) releaseCgroup(instance *ProcessInstance, group *cgroup.Group) {
+	if group == nil {
+		return
+	}
+
+	if pids, err := group.Processes(); err == nil && len(pids) > 0 {
+		m.instanceLog(instance, EventProcessKilled).Warn("Killing %d leftover process(es) of %s in %s",
+			len(pids), instance.Name, group.Path())
+		if err := group.Kill(); err != nil {
+			m.logger.Error("Failed to kill leftover processes of %s: %v", instance.Name, err)
+		}
+	}
+
+	// El cgroup no queda vacío hasta que se recogen los procesos muertos
+	for attempt := 1; ; attempt++ {
+		err := group.Remove()
+		if err == nil {
+			return
+		}
+		if attempt == cgroupRemoveAttempts {
+			m.logger.Debug("Keeping cgroup of process %s: %v", instance.Name, err)
+			return
+		}
+		time.Sleep(cgroupRemoveDelay)
+	}
+}
+
+// removeCgroups elimina los cgroups de los programas al terminar (asume el lock)
+func (m *Manager) removeCgroups() {
+	if m.cgroups == nil {
+		return
+	}
+	for name := range m.config.Programs {
+		if err := m.cgroups.RemoveProgram(name); err != nil {
+			m.logger.Debug("Failed to remove cgroup of program %s: %v", name, err)
+		}
+	}
+	if err := m.cgroups.Remove(); err != nil {
+		m.logger.Debug("Failed to remove program cgroups: %v", err)
+	}
+}
@@ -14,6 +14,7 @@ func NewManager(cfg *config.Config, logger *logger.Logger) *Manager {
 		processes: make(map[string][]*ProcessInstance),
 		config:    cfg,
 		logger:    logger,
+		startTime: time.Now(),
 	}
 }
 
@@ -49,30 +50,17 @@ func (m *Manager) checkProcessStatus() {
 	
 	statusChanged := false
 	
-	for programName, instances := range m.processes {
+	for _, instances := range m.processes {
 		for _, instance := range instances {
-			if instance.State == StateRunning && instance.Cmd != nil && instance.Cmd.Process != nil {
+			if (instance.State == StateRunning || instance.State == StateStarting) && instance.Cmd != nil && instance.Cmd.Process != nil {
 				// Verificar si el proceso sigue vivo enviando señal 0
 				if err := instance.Cmd.Process.Signal(syscall.Signal(0)); err != nil {
 					// El proceso ya no existe
-					m.logger.Info("Process %s (PID %d) was killed externally", instance.Name, instance.PID)
+					m.instanceLog(instance, EventProcessExited).Warn("Process %s (PID %d) was killed externally", instance.Name, instance.PID)
 					instance.State = StateStopped
 					statusChanged = true
 					
-					// Intentar reiniciar si es necesario
-					if m.shouldRestart(instance, 143) && instance.RestartCount < instance.Config.StartRetries {
-						m.logger.Info("Attempting to restart externally killed process %s", instance.Name)
-						go func(inst *ProcessInstance, progName string) {
-							time.Sleep(time.Second)
-							m.mutex.Lock()
-							defer m.mutex.Unlock()
-							if err := m.startProcessInstance(inst, progName); err != nil {
-								m.logger.Error("Failed to restart process %s: %v", inst.Name, err)
-								inst.State = StateFailed
-								m.broadcastStatus()
-							}
-						}(instance, programName)
-					}
+					// El reinicio, con su backoff, lo decide monitorProcess al recoger el proceso
 				}
 			}
 		}
@@ -87,8 +75,8 @@ func (m *Manager) checkProcessStatus() {
 func (m *Manager) StartAutoStartProcesses() error {
 	var errors []string
 
-	for name, program := range m.config.Programs {
-		if program.AutoStart {
+	for _, name := range m.config.StartOrder() {
+		if m.config.Programs[name].AutoStart {
 			if err := m.StartProgram(name); err != nil {
 				m.logger.Error("Failed to start program %s: %v", name, err)
 				errors = append(errors, fmt.Sprintf("%s: %v", name, err))
@@ -102,8 +90,12 @@ func (m *Manager) StartAutoStartProcesses() error {
 	return nil
 }
 
-// StartProgram inicia un programa específico
+// StartProgram inicia un programa específico una vez que sus dependencias están RUNNING
 func (m *Manager) StartProgram(name string) error {
+	if err := m.waitForDependencies(name); err != nil {
+		return err
+	}
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -118,6 +110,20 @@ func (m *Manager) StopProgram(name string) error {
 	return m.stopProgramUnsafe(name)
 }
 
+// RestartProgram detiene y vuelve a iniciar un programa específico
+func (m *Manager) RestartProgram(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.processes[name]; exists {
+		if err := m.stopProgramUnsafe(name); err != nil {
+			return fmt.Errorf("failed to stop program for restart: %w", err)
+		}
+	}
+
+	return m.startProgramWhenReady(name)
+}
+
 // GetStatus devuelve el estado actual de todos los procesos
 func (m *Manager) GetStatus() map[string][]*ProcessInstance {
 	m.mutex.RLock()
@@ -126,17 +132,53 @@ func (m *Manager) GetStatus() map[string][]*ProcessInstance {
 	return m.copyProcessMap()
 }
 
-// ReloadConfig recarga la configuración y aplica los cambios
-func (m *Manager) ReloadConfig(configFile string) error {
+// ReloadConfig recarga la configuración, aplica los cambios y devuelve un
+// informe con la acción tomada en cada programa. Si algún programa nuevo o
+// modificado no llega a RUNNING, se vuelve a la configuración anterior.
+func (m *Manager) ReloadConfig(configFile string) (*ReloadReport, error) {
+	m.reloadMutex.Lock()
+	defer m.reloadMutex.Unlock()
+
+	newConfig, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	oldConfig := m.config
+	wasActive := m.activePrograms()
+	m.warnUnappliedCgroupLimits(oldConfig, newConfig)
+	report := m.applyConfigChanges(newConfig)
+	m.mutex.Unlock()
+
+	// La verificación se hace sin el lock para que los procesos puedan avanzar
+	if err := m.verifyReload(report); err != nil {
+		m.logger.With(logger.Fields{Event: EventConfigRolledBack}).Error("Reload failed, rolling back to the previous configuration: %v", err)
+
+		m.mutex.Lock()
+		m.rollbackReload(report, oldConfig, wasActive, err)
+		m.mutex.Unlock()
+		return report, nil
+	}
+
+	m.logger.With(logger.Fields{Event: EventConfigReloaded}).Info("Configuration reloaded successfully")
+	return report, nil
+}
+
+// PlanReload carga el fichero de configuración y devuelve el plan de recarga
+// sin aplicar ningún cambio
+func (m *Manager) PlanReload(configFile string) (*ReloadReport, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
 
 	newConfig, err := config.Load(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	return m.applyConfigChanges(newConfig)
+	report := m.planReload(newConfig)
+	report.DryRun = true
+	return report, nil
 }
 
 // CleanupDeadProcesses elimina todas las instancias de procesos muertos
@@ -0,0 +1,124 @@
+package process
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// processCounters acumula contadores monótonos para las métricas; a diferencia
+// de RestartCount, no vuelven a cero cuando una instancia llega a RUNNING
+type processCounters struct {
+	mutex    sync.Mutex
+	restarts map[restartKey]uint64
+	exits    map[exitKey]uint64
+}
+
+type restartKey struct {
+	program  string
+	index    int
+	instance string
+	reason   string
+}
+
+type exitKey struct {
+	program  string
+	exitCode int
+}
+
+// recordRestart cuenta un reinicio automático de una instancia por un motivo
+func (c *processCounters) recordRestart(program string, index int, instance, reason string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.restarts == nil {
+		c.restarts = make(map[restartKey]uint64)
+	}
+	c.restarts[restartKey{program, index, instance, reason}]++
+}
+
+// recordExit cuenta una salida de un proceso de un programa con su código
+func (c *processCounters) recordExit(program string, exitCode int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.exits == nil {
+		c.exits = make(map[exitKey]uint64)
+	}
+	c.exits[exitKey{program, exitCode}]++
+}
+
+// RestartCounter es el número de reinicios automáticos de una instancia por
+// un motivo (RestartReasonExit o uno de los límites del watchdog)
+type RestartCounter struct {
+	Program  string
+	Index    int
+	Instance string
+	Reason   string
+	Count    uint64
+}
+
+// ExitCounter es el número de salidas de un programa con un código
+type ExitCounter struct {
+	Program  string
+	ExitCode int
+	Count    uint64
+}
+
+// Metrics es una instantánea de los datos que exporta /metrics
+type Metrics struct {
+	StartTime time.Time // arranque del supervisor
+	Programs  int       // programas configurados
+	Instances []InstanceInfo
+	Restarts  []RestartCounter
+	Exits     []ExitCounter
+}
+
+// States devuelve todos los estados posibles de una instancia
+func States() []ProcessState {
+	var states []ProcessState
+	for state := StateStopped; state.String() != "UNKNOWN"; state++ {
+		states = append(states, state)
+	}
+	return states
+}
+
+// Metrics devuelve el estado de las instancias y los contadores acumulados,
+// ordenados para que la salida sea estable
+func (m *Manager) Metrics() Metrics {
+	metrics := Metrics{
+		StartTime: m.startTime,
+		Instances: m.Snapshot(),
+	}
+
+	m.mutex.RLock()
+	metrics.Programs = len(m.config.Programs)
+	m.mutex.RUnlock()
+
+	m.counters.mutex.Lock()
+	for key, count := range m.counters.restarts {
+		metrics.Restarts = append(metrics.Restarts, RestartCounter{Program: key.program, Index: key.index, Instance: key.instance, Reason: key.reason, Count: count})
+	}
+	for key, count := range m.counters.exits {
+		metrics.Exits = append(metrics.Exits, ExitCounter{Program: key.program, ExitCode: key.exitCode, Count: count})
+	}
+	m.counters.mutex.Unlock()
+
+	sort.Slice(metrics.Restarts, func(i, j int) bool {
+		a, b := metrics.Restarts[i], metrics.Restarts[j]
+		if a.Program != b.Program {
+			return a.Program < b.Program
+		}
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		return a.Reason < b.Reason
+	})
+	sort.Slice(metrics.Exits, func(i, j int) bool {
+		if metrics.Exits[i].Program != metrics.Exits[j].Program {
+			return metrics.Exits[i].Program < metrics.Exits[j].Program
+		}
+		return metrics.Exits[i].ExitCode < metrics.Exits[j].ExitCode
+	})
+	return metrics
+}
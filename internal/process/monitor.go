@@ -6,28 +6,111 @@ import (
 	"time"
 )
 
-// monitorProcess monitorea un proceso en ejecución
-func (m *Manager) monitorProcess(instance *ProcessInstance, programName string) {
-	time.Sleep(time.Duration(instance.Config.StartTime) * time.Second)
+// monitorProcess monitorea un proceso en ejecución. La instancia permanece en
+// STARTING hasta que transcurre starttime; sólo entonces pasa a RUNNING. Las
+// esperas se hacen sin el lock, que se toma para cada cambio de estado.
+func (m *Manager) monitorProcess(instance *ProcessInstance, programName string, startTime time.Duration) {
+	exited := instance.exited
+	output := instance.output
+	group := instance.cgroup
+	waitResult := make(chan error, 1)
+	go func() {
+		waitResult <- instance.Cmd.Wait()
+	}()
 
-	if instance.Cmd.ProcessState == nil {
-		instance.State = StateRunning
-		m.logger.Info("Process %s successfully started and running", instance.Name)
-		m.broadcastStatus()
+	startTimer := time.NewTimer(startTime)
+	defer startTimer.Stop()
+
+	var err error
+	started := false
+	select {
+	case err = <-waitResult:
+	case <-startTimer.C:
+		started = true
+		// Quien tenga el lock puede estar esperando a exited: el cambio a
+		// RUNNING no debe retrasar la recogida del proceso
+		go m.markRunning(instance, exited)
+		err = <-waitResult
 	}
+	m.closeOutput(output)
+	m.releaseCgroup(instance, group)
+	close(exited)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Si quien esperaba exited ya volvió a arrancar la instancia, la nueva
+	// ejecución es suya y ésta cuenta como parada
+	current := instance.exited == exited
+	manualStop := instance.ManualStop || !current
 
-	err := instance.Cmd.Wait()
 	exitCode := m.getExitCode(err)
-	instance.ExitCode = exitCode
+	if current {
+		instance.ExitCode = exitCode
+	}
+	m.counters.recordExit(programName, exitCode)
 
-	if instance.ManualStop {
-		m.logger.Info("Process %s stopped gracefully", instance.Name)
+	if manualStop {
+		m.instanceExitLog(instance, EventProcessStopped, exitCode).Info("Process %s stopped gracefully", instance.Name)
+		if current {
+			instance.State = StateStopped
+			m.broadcastStatus()
+		}
+		return
+	}
+
+	if !started {
+		m.handleStartFailure(instance, programName, exitCode)
+		return
+	}
+
+	m.handleProcessExit(instance, programName, exitCode, err)
+}
+
+// markRunning pasa a RUNNING una instancia que superó starttime, salvo que
+// entretanto se haya detenido o su proceso haya terminado
+func (m *Manager) markRunning(instance *ProcessInstance, exited chan struct{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+	if instance.exited != exited || instance.ManualStop || instance.State != StateStarting {
+		return
+	}
+
+	instance.State = StateRunning
+	// Tras un reinicio por límite no basta con arrancar: los reintentos se
+	// reinician cuando una muestra la ve dentro de sus límites
+	if !instance.overLimit {
+		instance.RestartCount = 0
+	}
+	m.instanceLog(instance, EventProcessRunning).Info("Process %s successfully started and running", instance.Name)
+	m.broadcastStatus()
+}
+
+// handleStartFailure trata una salida antes de starttime como un intento de
+// arranque fallido, que se reintenta sea cual sea la política de autorestart
+// (asume el lock)
+func (m *Manager) handleStartFailure(instance *ProcessInstance, programName string, exitCode int) {
+	m.instanceExitLog(instance, EventProcessStartFailed, exitCode).Error("Process %s exited with code %d before starttime (%ds), start attempt failed",
+		instance.Name, exitCode, instance.Config.StartTime)
+
+	if m.shuttingDown.Load() {
 		instance.State = StateStopped
 		m.broadcastStatus()
 		return
 	}
 
-	m.handleProcessExit(instance, programName, exitCode, err)
+	if instance.RestartCount >= instance.Config.StartRetries {
+		m.markFatal(instance)
+		return
+	}
+
+	m.attemptRestart(instance, programName, RestartReasonExit)
 }
 
 // getExitCode extrae el código de salida de un error
@@ -61,61 +144,112 @@ func (m *Manager) getExitCode(err error) int {
 	return status.ExitStatus()
 }
 
-// handleProcessExit maneja la salida de un proceso
+// handleProcessExit maneja la salida de un proceso (asume el lock)
 func (m *Manager) handleProcessExit(instance *ProcessInstance, programName string, exitCode int, err error) {
 	if err != nil {
-		m.logger.Error("Process %s exited with code %d", instance.Name, exitCode)
+		m.instanceExitLog(instance, EventProcessExited, exitCode).Error("Process %s exited with code %d", instance.Name, exitCode)
 	} else {
-		m.logger.Info("Process %s exited normally", instance.Name)
+		m.instanceExitLog(instance, EventProcessExited, exitCode).Info("Process %s exited normally", instance.Name)
 	}
 
-	if m.shouldRestart(instance, exitCode) && instance.RestartCount < instance.Config.StartRetries {
-		m.attemptRestart(instance, programName)
-	} else {
+	if m.shuttingDown.Load() {
+		instance.State = StateStopped
+		m.broadcastStatus()
+		return
+	}
+
+	if !m.shouldRestart(instance, exitCode) {
 		m.finalizeProcess(instance, exitCode)
+		return
 	}
-}
 
-// attemptRestart intenta reiniciar un proceso
-func (m *Manager) attemptRestart(instance *ProcessInstance, programName string) {
-	m.logger.Info("Restarting process %s (attempt %d/%d)",
-		instance.Name, instance.RestartCount+1, instance.Config.StartRetries)
+	if instance.RestartCount >= instance.Config.StartRetries {
+		m.markFatal(instance)
+		return
+	}
 
-	instance.State = StateRestarting
-	instance.RestartCount++
-	m.broadcastStatus()
+	m.attemptRestart(instance, programName, RestartReasonExit)
+}
 
-	time.Sleep(time.Second)
+// attemptRestart espera el backoff correspondiente y reinicia el proceso por
+// reason, reintentando mientras queden intentos si el arranque falla. Suelta
+// el lock mientras espera (asume el lock)
+func (m *Manager) attemptRestart(instance *ProcessInstance, programName, reason string) {
+	for {
+		instance.RestartCount++
+		delay := backoffDelay(instance.Config, instance.RestartCount)
 
-	if err := m.startProcessInstance(instance, programName); err != nil {
-		m.logger.Error("Failed to restart process %s: %v", instance.Name, err)
-		instance.State = StateFailed
+		m.instanceLog(instance, EventProcessBackoff).Info("Restarting process %s in %s (attempt %d/%d)",
+			instance.Name, delay.Round(time.Millisecond), instance.RestartCount, instance.Config.StartRetries)
+
+		instance.State = StateBackoff
 		m.broadcastStatus()
+
+		m.mutex.Unlock()
+		select {
+		case <-time.After(delay):
+			m.mutex.Lock()
+		case <-instance.StopChan:
+			m.mutex.Lock()
+			m.logger.Info("Pending restart of process %s cancelled", instance.Name)
+			return
+		}
+
+		if !m.restartAfterBackoff(instance, programName, reason) {
+			return
+		}
+
+		if instance.RestartCount >= instance.Config.StartRetries {
+			m.markFatal(instance)
+			return
+		}
 	}
 }
 
-// finalizeProcess finaliza un proceso que no se puede reiniciar
-func (m *Manager) finalizeProcess(instance *ProcessInstance, exitCode int) {
-	if instance.RestartCount >= instance.Config.StartRetries {
-		m.logger.Error("Process %s failed too many times, giving up", instance.Name)
-		instance.State = StateFailed
+// restartAfterBackoff inicia de nuevo la instancia y devuelve true si el
+// arranque falló y debe reintentarse (asume el lock)
+func (m *Manager) restartAfterBackoff(instance *ProcessInstance, programName, reason string) bool {
+	if m.shuttingDown.Load() || instance.ManualStop {
+		instance.State = StateStopped
 		m.broadcastStatus()
-		return
+		return false
+	}
+
+	if err := m.startProcessInstance(instance, programName); err != nil {
+		m.logger.Error("Failed to restart process %s: %v", instance.Name, err)
+		return true
+	}
+	instance.RestartReason = reason
+	m.counters.recordRestart(programName, instance.Index, instance.Name, reason)
+	if reason != RestartReasonExit {
+		m.instanceLog(instance, EventProcessStarted).Info("Restarted process %s (PID: %d) after exceeding %s",
+			instance.Name, instance.PID, reason)
 	}
+	return false
+}
 
+// markFatal marca una instancia como FATAL tras agotar sus reintentos (asume el lock)
+func (m *Manager) markFatal(instance *ProcessInstance) {
+	m.instanceExitLog(instance, EventProcessFatal, instance.ExitCode).Error("Process %s failed %d times in a row, giving up", instance.Name, instance.RestartCount)
+	instance.State = StateFatal
+	m.broadcastStatus()
+}
+
+// finalizeProcess finaliza un proceso que no debe reiniciarse (asume el lock)
+func (m *Manager) finalizeProcess(instance *ProcessInstance, exitCode int) {
 	if instance.ManualStop {
 		// Detenido intencionalmente por nuestro programa taskmaster (comando stop/restart)
-		m.logger.Info("Process %s stopped by taskmaster", instance.Name)
+		m.instanceExitLog(instance, EventProcessStopped, exitCode).Info("Process %s stopped by taskmaster", instance.Name)
 		instance.State = StateStopped
 	} else {
 		// Terminación externa - distinguir entre natural y anómala
 		if m.isExpectedExitCode(exitCode, instance.Config.ExitCodes) {
 			// Exit code esperado = terminación natural/limpia
-			m.logger.Info("Process %s terminated naturally with expected code %d", instance.Name, exitCode)
+			m.instanceExitLog(instance, EventProcessStopped, exitCode).Info("Process %s terminated naturally with expected code %d", instance.Name, exitCode)
 			instance.State = StateStopped // ← Cambio: STOPPED en lugar de FAILED
 		} else {
 			// Exit code inesperado = algo salió mal
-			m.logger.Info("Process %s terminated with unexpected code %d", instance.Name, exitCode)
+			m.instanceExitLog(instance, EventProcessExited, exitCode).Warn("Process %s terminated with unexpected code %d", instance.Name, exitCode)
 			instance.State = StateFailed
 		}
 	}
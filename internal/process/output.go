@@ -0,0 +1,195 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"taskmaster/internal/logfile"
+	"time"
+)
+
+// outputWaitDelay limita cuánto espera Wait a que se cierren las tuberías de
+// salida cuando un descendiente las mantiene abiertas tras salir el proceso
+const outputWaitDelay = 2 * time.Second
+
+// outputFiles comparte un logfile.File por ruta entre todas las instancias que
+// escriben en ella, para que la rotación la haga un único escritor
+type outputFiles struct {
+	mutex sync.Mutex
+	files map[string]*sharedOutput
+}
+
+type sharedOutput struct {
+	file *logfile.File
+	refs int
+}
+
+// acquire abre (o reutiliza) el fichero de una ruta y aplica sus opciones de rotación
+func (o *outputFiles) acquire(path string, opts logfile.Options) (*logfile.File, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.files == nil {
+		o.files = make(map[string]*sharedOutput)
+	}
+
+	if shared, exists := o.files[path]; exists {
+		shared.refs++
+		shared.file.SetOptions(opts)
+		return shared.file, nil
+	}
+
+	file, err := logfile.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	o.files[path] = &sharedOutput{file: file, refs: 1}
+	return file, nil
+}
+
+// release cierra el fichero de una ruta cuando ya nadie escribe en él
+func (o *outputFiles) release(path string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	shared, exists := o.files[path]
+	if !exists {
+		return
+	}
+	shared.refs--
+	if shared.refs <= 0 {
+		shared.file.Close()
+		delete(o.files, path)
+	}
+}
+
+// configure actualiza las opciones de rotación de una ruta abierta
+func (o *outputFiles) configure(path string, opts logfile.Options) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if shared, exists := o.files[path]; exists {
+		shared.file.SetOptions(opts)
+	}
+}
+
+// reopen vuelve a abrir todos los ficheros abiertos y devuelve las rutas que fallaron
+func (o *outputFiles) reopen() map[string]error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	failed := make(map[string]error)
+	for path, shared := range o.files {
+		if err := shared.file.Reopen(); err != nil {
+			failed[path] = err
+		}
+	}
+	return failed
+}
+
+// ReopenLogs vuelve a abrir los ficheros de salida de los procesos, para que
+// herramientas externas como logrotate puedan moverlos
+func (m *Manager) ReopenLogs() error {
+	failed := m.outputs.reopen()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(failed))
+	for path, err := range failed {
+		m.logger.Error("Failed to reopen output log %s: %v", path, err)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("failed to reopen %s", strings.Join(paths, ", "))
+}
+
+// outputWriter escribe la salida de una instancia en su fichero sin devolver
+// nunca error: si el copiado se detuviera, el hijo se bloquearía en la tubería
+type outputWriter struct {
+	manager  *Manager
+	instance string
+	stream   string
+	file     *logfile.File
+	failing  bool
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	if _, err := w.file.Write(p); err != nil {
+		if !w.failing {
+			w.manager.logger.Error("Failed to write %s of process %s to %s: %v",
+				w.stream, w.instance, w.file.Path(), err)
+		}
+		w.failing = true
+	} else if w.failing {
+		w.manager.logger.Info("Writing %s of process %s to %s again", w.stream, w.instance, w.file.Path())
+		w.failing = false
+	}
+	return len(p), nil
+}
+
+// outputRun agrupa lo abierto para capturar la salida de una ejecución
+type outputRun struct {
+	files []string
+	tails []*tailWriter
+}
+
+// configureRedirections conecta stdout y stderr a tuberías cuya salida guarda
+// el supervisor en memoria para tail y escribe en los ficheros configurados,
+// rotándolos según sus opciones
+func (m *Manager) configureRedirections(cmd *exec.Cmd, instance *ProcessInstance) error {
+	run := &outputRun{}
+
+	stdout, err := m.openOutput(instance, run, StreamStdout, instance.Config.Stdout, instance.Config.StdoutLog)
+	if err != nil {
+		return err
+	}
+	stderr, err := m.openOutput(instance, run, StreamStderr, instance.Config.Stderr, instance.Config.StderrLog)
+	if err != nil {
+		m.closeOutput(run)
+		return err
+	}
+
+	instance.output = run
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = outputWaitDelay
+	return nil
+}
+
+// openOutput devuelve el writer de un flujo de la instancia: siempre al búfer
+// de tail y, si hay ruta configurada, también al fichero
+func (m *Manager) openOutput(instance *ProcessInstance, run *outputRun, stream, path string, opts logfile.Options) (io.Writer, error) {
+	tail := m.newTailWriter(instance, stream)
+	run.tails = append(run.tails, tail)
+
+	if path == "" || path == "/dev/null" {
+		return tail, nil
+	}
+
+	file, err := m.outputs.acquire(path, opts)
+	if err != nil {
+		m.logger.Error("Failed to open %s log %s for process %s: %v", stream, path, instance.Name, err)
+		return nil, err
+	}
+	run.files = append(run.files, path)
+
+	writer := &outputWriter{manager: m, instance: instance.Name, stream: stream, file: file}
+	return io.MultiWriter(writer, tail), nil
+}
+
+// closeOutput vuelca las líneas a medias y libera los ficheros de una ejecución
+func (m *Manager) closeOutput(run *outputRun) {
+	if run == nil {
+		return
+	}
+	for _, tail := range run.tails {
+		tail.flush()
+	}
+	for _, path := range run.files {
+		m.outputs.release(path)
+	}
+}
@@ -0,0 +1,89 @@
+package process
+
+import (
+	"fmt"
+	"taskmaster/internal/config"
+)
+
+// Acciones aplicadas a un programa durante una recarga
+const (
+	ActionAdded     = "added"
+	ActionRemoved   = "removed"
+	ActionRestarted = "restarted"
+	ActionScaled    = "scaled"
+	ActionUpdated   = "updated"
+	ActionStarted   = "started"
+	ActionStopped   = "stopped"
+)
+
+// ProgramReload describe qué cambió en un programa y qué se hizo con él
+type ProgramReload struct {
+	Program string               `json:"program"`
+	Action  string               `json:"action"`
+	Changes []config.FieldChange `json:"changes,omitempty"`
+	Detail  string               `json:"detail,omitempty"` // efecto sobre los procesos
+	Error   string               `json:"error,omitempty"`
+}
+
+// ReloadReport resume el resultado de una recarga de configuración. En un
+// dry run describe el plan sin que se haya aplicado nada. Si la recarga
+// falló, Rollback recoge lo que se hizo para volver a la configuración anterior.
+type ReloadReport struct {
+	DryRun         bool            `json:"dry_run,omitempty"`
+	Programs       []ProgramReload `json:"programs"`
+	RolledBack     bool            `json:"rolled_back,omitempty"`
+	RollbackReason string          `json:"rollback_reason,omitempty"`
+	Rollback       []ProgramReload `json:"rollback,omitempty"`
+}
+
+// Lines devuelve el informe como líneas de texto legibles
+func (r *ReloadReport) Lines() []string {
+	var lines []string
+	if r.DryRun {
+		lines = append(lines, "Dry run: no changes applied")
+	}
+	if len(r.Programs) == 0 {
+		lines = append(lines, "No configuration changes")
+	}
+	for _, program := range r.Programs {
+		lines = append(lines, program.lines("")...)
+	}
+
+	if r.RolledBack {
+		lines = append(lines, fmt.Sprintf("Rolled back to the previous configuration: %s", r.RollbackReason))
+		for _, program := range r.Rollback {
+			lines = append(lines, program.lines("  ")...)
+		}
+	}
+	return lines
+}
+
+// lines formatea la acción de un programa y sus cambios con la sangría indicada
+func (p ProgramReload) lines(indent string) []string {
+	line := fmt.Sprintf("%s%s: %s", indent, p.Program, p.Action)
+	if p.Detail != "" {
+		line += fmt.Sprintf(" (%s)", p.Detail)
+	}
+	if p.Error != "" {
+		line += fmt.Sprintf(" (error: %s)", p.Error)
+	}
+
+	lines := []string{line}
+	for _, change := range p.Changes {
+		lines = append(lines, fmt.Sprintf("%s    %s: %s -> %s [%s]", indent, change.Field, change.Old, change.New, change.Apply))
+	}
+	return lines
+}
+
+// Failed indica si alguna acción de la recarga falló o si hubo que deshacerla
+func (r *ReloadReport) Failed() bool {
+	if r.RolledBack {
+		return true
+	}
+	for _, program := range r.Programs {
+		if program.Error != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package process
+
+import (
+	"fmt"
+	"taskmaster/internal/config"
+	"time"
+)
+
+// reloadVerifyGrace es el margen sobre starttime para que un programa
+// recargado llegue a RUNNING antes de dar la recarga por fallida
+const reloadVerifyGrace = 2 * time.Second
+
+// verifyReload comprueba que la recarga se aplicó sin errores y que los
+// programas añadidos, reiniciados o escalados llegan a RUNNING
+func (m *Manager) verifyReload(report *ReloadReport) error {
+	for _, program := range report.Programs {
+		if program.Error != "" {
+			return fmt.Errorf("program %s: %s", program.Program, program.Error)
+		}
+	}
+
+	for _, name := range m.reloadTargets(report) {
+		m.mutex.RLock()
+		startTime := m.config.Programs[name].StartTime
+		m.mutex.RUnlock()
+
+		timeout := time.Duration(startTime)*time.Second + reloadVerifyGrace
+		if err := m.waitForStarted(name, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadTargets devuelve los programas en los que la recarga lanzó procesos nuevos
+func (m *Manager) reloadTargets(report *ReloadReport) []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var targets []string
+	for _, program := range report.Programs {
+		switch program.Action {
+		case ActionAdded, ActionRestarted, ActionScaled:
+			if active, _ := m.HasActiveProcesses(program.Program); active {
+				targets = append(targets, program.Program)
+			}
+		}
+	}
+	return targets
+}
+
+// waitForStarted espera a que todas las instancias de un programa superen
+// starttime en el primer intento: cualquier salida cuenta como fallo
+func (m *Manager) waitForStarted(name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ready, err := m.startedOnFirstAttempt(name)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("program %s did not reach RUNNING within %s", name, timeout)
+		}
+		time.Sleep(readinessPollInterval)
+	}
+}
+
+// startedOnFirstAttempt indica si las instancias de un programa están RUNNING
+// sin haber necesitado reintentos
+func (m *Manager) startedOnFirstAttempt(name string) (bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	ready := true
+	for _, instance := range m.processes[name] {
+		switch instance.State {
+		case StateRunning:
+		case StateStarting:
+			ready = false
+		default:
+			return false, fmt.Errorf("instance %s of program %s is %s (exit code %d)",
+				instance.Name, name, instance.State, instance.ExitCode)
+		}
+	}
+	return ready, nil
+}
+
+// activePrograms devuelve los programas con instancias activas (asume el lock)
+func (m *Manager) activePrograms() map[string]bool {
+	active := make(map[string]bool)
+	for name := range m.processes {
+		if isActive, _ := m.HasActiveProcesses(name); isActive {
+			active[name] = true
+		}
+	}
+	return active
+}
+
+// rollbackReload vuelve a la configuración anterior y deja en marcha los
+// mismos programas que antes de la recarga (asume el lock)
+func (m *Manager) rollbackReload(report *ReloadReport, oldConfig *config.Config, wasActive map[string]bool, reason error) {
+	rollback := m.applyConfigChanges(oldConfig)
+
+	for _, name := range oldConfig.StartOrder() {
+		isActive, _ := m.HasActiveProcesses(name)
+		switch {
+		case wasActive[name] && !isActive:
+			m.logger.Info("Rollback: restarting previously running program %s", name)
+			result := ProgramReload{Program: name, Action: ActionStarted, Detail: "was running before the reload"}
+			if err := m.startProgramWhenReady(name); err != nil {
+				result.Error = err.Error()
+			}
+			rollback.Programs = append(rollback.Programs, result)
+		case !wasActive[name] && isActive:
+			m.logger.Info("Rollback: stopping program %s, it was not running before the reload", name)
+			result := ProgramReload{Program: name, Action: ActionStopped, Detail: "was not running before the reload"}
+			if err := m.stopProgramUnsafe(name); err != nil {
+				result.Error = err.Error()
+			}
+			rollback.Programs = append(rollback.Programs, result)
+		}
+	}
+
+	report.RolledBack = true
+	report.RollbackReason = reason.Error()
+	report.Rollback = rollback.Programs
+
+	m.logger.Info("Configuration rolled back: %s", report.RollbackReason)
+}
@@ -0,0 +1,51 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadRollsBackFailedProgram(t *testing.T) {
+	const before = "programs:\n  web:\n    cmd: \"sleep 100\"\n    autostart: false\n    starttime: 0\n"
+	m := newTestManager(t, before)
+	if err := m.StartProgram("web"); err != nil {
+		t.Fatalf("StartProgram() error: %v", err)
+	}
+	oldConfig := m.config
+
+	// crash sale nada más arrancar y sin reintentos queda FATAL
+	path := filepath.Join(t.TempDir(), "taskmaster.yml")
+	after := before + "  crash:\n    cmd: \"false\"\n    autostart: true\n    starttime: 1\n    startretries: 0\n"
+	if err := os.WriteFile(path, []byte(after), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := m.ReloadConfig(path)
+	if err != nil {
+		t.Fatalf("ReloadConfig() error: %v", err)
+	}
+	if !report.RolledBack {
+		t.Fatalf("ReloadConfig() did not roll back: %v", report.Lines())
+	}
+	want := "instance crash_0 of program crash is FATAL (exit code 1)"
+	if report.RollbackReason != want {
+		t.Errorf("RollbackReason = %q, want %q", report.RollbackReason, want)
+	}
+
+	if _, exists := m.Program("crash"); exists {
+		t.Errorf("program crash is still listed after the rollback")
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.config != oldConfig {
+		t.Errorf("configuration was not restored")
+	}
+	if active, _ := m.HasActiveProcesses("crash"); active {
+		t.Errorf("crash is still running after the rollback")
+	}
+	if active, _ := m.HasActiveProcesses("web"); !active {
+		t.Errorf("web is no longer running after the rollback")
+	}
+}
@@ -0,0 +1,85 @@
+package process
+
+import (
+	"fmt"
+	"maps"
+	"taskmaster/internal/config"
+)
+
+// ScaleProgram ajusta en caliente el número de instancias de un programa: sólo
+// arranca las que faltan o detiene las sobrantes, empezando por el índice más alto.
+// El nuevo número no llega al fichero: la siguiente recarga compara el fichero
+// con la configuración escalada y vuelve al numprocs configurado
+func (m *Manager) ScaleProgram(name string, numProcs int) error {
+	if numProcs < 1 {
+		return fmt.Errorf("numprocs must be at least 1, got %d", numProcs)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	program, exists := m.config.Programs[name]
+	if !exists {
+		return fmt.Errorf("program %s not found in configuration", name)
+	}
+
+	oldNumProcs := program.NumProcs
+	program.NumProcs = numProcs
+
+	// La configuración cargada puede estar en uso (una recarga guarda la
+	// anterior para deshacerla): se sustituye por una copia en vez de modificarla
+	scaled := *m.config
+	scaled.Programs = maps.Clone(m.config.Programs)
+	scaled.Programs[name] = program
+	m.config = &scaled
+
+	return m.scaleProgramUnsafe(name, oldNumProcs, program)
+}
+
+// scaleProgramUnsafe aplica un cambio de numprocs a las instancias existentes (asume el lock)
+func (m *Manager) scaleProgramUnsafe(name string, oldNumProcs int, program config.Program) error {
+	if oldNumProcs == program.NumProcs {
+		return nil
+	}
+
+	m.programLog(name, EventProgramScaled).Info("Scaling program %s from %d to %d instance(s)", name, oldNumProcs, program.NumProcs)
+
+	// Las instancias comparten su configuración: cada una recibe una copia con
+	// el nuevo numprocs en lugar de modificar la compartida
+	instances := m.processes[name]
+	replaced := make(map[*ProcessConfig]*ProcessConfig)
+	for _, instance := range instances {
+		updated, exists := replaced[instance.Config]
+		if !exists {
+			copied := *instance.Config
+			copied.NumProcs = program.NumProcs
+			updated = &copied
+			replaced[instance.Config] = updated
+		}
+		instance.Config = updated
+	}
+
+	// Detener las instancias sobrantes, de la más alta a la más baja
+	for i := len(instances) - 1; i >= 0; i-- {
+		instance := instances[i]
+		if instance.Index < program.NumProcs {
+			continue
+		}
+		if m.isActiveInstance(instance) {
+			m.logger.Info("Stopping surplus instance %s", instance.Name)
+			m.stopProcessInstance(instance)
+		}
+		m.removeInstance(name, instance.Index)
+	}
+
+	// Sólo se añaden instancias si el programa está en marcha
+	if active, _ := m.HasActiveProcesses(name); !active || program.NumProcs < oldNumProcs {
+		m.broadcastStatus()
+		return nil
+	}
+
+	processConfig := m.programProcessConfig(name, program)
+	err := m.createAndStartInstanceRange(name, oldNumProcs, program.NumProcs, processConfig)
+	m.broadcastStatus()
+	return err
+}
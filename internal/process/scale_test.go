@@ -0,0 +1,44 @@
+package process
+
+import "testing"
+
+func TestScaleProgramKeepsSharedConfigs(t *testing.T) {
+	m := newTestManager(t, "programs:\n  pool:\n    cmd: \"sleep 100\"\n    numprocs: 3\n    autostart: false\n    starttime: 0\n")
+	if err := m.StartProgram("pool"); err != nil {
+		t.Fatalf("StartProgram() error: %v", err)
+	}
+
+	m.mutex.RLock()
+	loaded := m.config
+	shared := m.processes["pool"][0].Config
+	m.mutex.RUnlock()
+
+	if err := m.ScaleProgram("pool", 2); err != nil {
+		t.Fatalf("ScaleProgram() error: %v", err)
+	}
+
+	if got := loaded.Programs["pool"].NumProcs; got != 3 {
+		t.Errorf("loaded config numprocs = %d, want 3 (left untouched)", got)
+	}
+	if got := shared.NumProcs; got != 3 {
+		t.Errorf("shared process config numprocs = %d, want 3 (left untouched)", got)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if got := m.config.Programs["pool"].NumProcs; got != 2 {
+		t.Errorf("current config numprocs = %d, want 2", got)
+	}
+	instances := m.processes["pool"]
+	if len(instances) != 2 {
+		t.Fatalf("%d instances after scaling, want 2", len(instances))
+	}
+	for _, instance := range instances {
+		if instance.Config.NumProcs != 2 {
+			t.Errorf("%s numprocs = %d, want 2", instance.Name, instance.Config.NumProcs)
+		}
+	}
+	if instances[0].Config != instances[1].Config {
+		t.Errorf("instances no longer share their process config")
+	}
+}
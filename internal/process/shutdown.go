@@ -0,0 +1,144 @@
+package process
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// killWait es el tiempo que se espera a que un proceso desaparezca tras SIGKILL
+const killWait = 2 * time.Second
+
+// ShutdownReport resume el resultado de una parada global del supervisor
+type ShutdownReport struct {
+	Clean    []string      // instancias que terminaron con su señal de parada
+	Killed   []string      // instancias que hubo que matar con SIGKILL
+	Duration time.Duration // tiempo total empleado
+}
+
+// Shutdown detiene todos los programas recorriendo el grafo de dependencias y
+// las prioridades en orden inverso al arranque. Las instancias de una misma
+// oleada se detienen en paralelo respetando su StopSignal y StopTime; al
+// alcanzar el timeout global se mata todo lo que quede.
+func (m *Manager) Shutdown(timeout time.Duration) *ShutdownReport {
+	m.shuttingDown.Store(true)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	report := &ShutdownReport{}
+	var reportMutex sync.Mutex
+
+	m.cancelPendingRestarts()
+
+	for _, wave := range m.shutdownWaves() {
+		var wg sync.WaitGroup
+		for _, instance := range wave {
+			wg.Add(1)
+			go func(instance *ProcessInstance) {
+				defer wg.Done()
+				killed := m.shutdownInstance(instance, deadline)
+
+				reportMutex.Lock()
+				defer reportMutex.Unlock()
+				if killed {
+					report.Killed = append(report.Killed, instance.Name)
+				} else {
+					report.Clean = append(report.Clean, instance.Name)
+				}
+			}(instance)
+		}
+		wg.Wait()
+	}
+
+	sort.Strings(report.Clean)
+	sort.Strings(report.Killed)
+	report.Duration = time.Since(start)
+
+	m.removeCgroups()
+	m.broadcastStatus()
+	return report
+}
+
+// cancelPendingRestarts detiene las instancias que esperan en BACKOFF
+func (m *Manager) cancelPendingRestarts() {
+	for _, instances := range m.processes {
+		for _, instance := range instances {
+			if instance.State == StateBackoff {
+				m.stopProcessInstance(instance)
+			}
+		}
+	}
+}
+
+// shutdownWaves agrupa las instancias vivas siguiendo el grafo de dependencias
+// en orden inverso; los programas ya eliminados de la configuración van primero
+func (m *Manager) shutdownWaves() [][]*ProcessInstance {
+	var waves [][]*ProcessInstance
+
+	var orphans []*ProcessInstance
+	for name, instances := range m.processes {
+		if _, exists := m.config.Programs[name]; !exists {
+			orphans = append(orphans, m.aliveInstances(instances)...)
+		}
+	}
+	if len(orphans) > 0 {
+		waves = append(waves, orphans)
+	}
+
+	for _, programs := range m.config.StopWaves() {
+		var wave []*ProcessInstance
+		for _, name := range programs {
+			wave = append(wave, m.aliveInstances(m.processes[name])...)
+		}
+		if len(wave) > 0 {
+			waves = append(waves, wave)
+		}
+	}
+	return waves
+}
+
+// aliveInstances filtra las instancias cuyo proceso sigue vivo
+func (m *Manager) aliveInstances(instances []*ProcessInstance) []*ProcessInstance {
+	var alive []*ProcessInstance
+	for _, instance := range instances {
+		if m.isAliveInstance(instance) {
+			alive = append(alive, instance)
+		}
+	}
+	return alive
+}
+
+// isAliveInstance verifica si una instancia tiene un proceso que aún no ha terminado
+func (m *Manager) isAliveInstance(instance *ProcessInstance) bool {
+	if instance.Cmd == nil || instance.Cmd.Process == nil || instance.exited == nil {
+		return false
+	}
+
+	select {
+	case <-instance.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// shutdownInstance detiene una instancia antes del deadline global y devuelve
+// true si hubo que matarla con SIGKILL
+func (m *Manager) shutdownInstance(instance *ProcessInstance, deadline time.Time) bool {
+	stopTimeout := time.Duration(instance.Config.StopTime) * time.Second
+	if remaining := time.Until(deadline); remaining < stopTimeout {
+		stopTimeout = remaining
+	}
+
+	if stopTimeout > 0 {
+		m.instanceLog(instance, EventProcessStopping).Info("Stopping process %s with signal %s (timeout: %s)",
+			instance.Name, instance.Config.StopSignal, stopTimeout.Round(time.Millisecond))
+	}
+
+	killed := m.stopRun(m.prepareStop(instance), stopTimeout)
+	instance.State = StateStopped
+	return killed
+}
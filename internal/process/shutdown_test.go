@@ -0,0 +1,131 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmaster/internal/config"
+	"taskmaster/internal/logfile"
+	"taskmaster/internal/logger"
+	"taskmaster/internal/procfs"
+)
+
+// newTestManager carga una configuración YAML y crea un gestor que registra en
+// un fichero temporal
+func newTestManager(t *testing.T, content string) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "taskmaster.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+
+	log, err := logger.New(filepath.Join(dir, "taskmaster.log"), logfile.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.SetConsole(false)
+	t.Cleanup(func() { log.Close() })
+
+	m := NewManager(cfg, log)
+	t.Cleanup(func() { m.Shutdown(5 * time.Second) })
+	return m
+}
+
+// startGroup arranca el programa group y espera a que su comando tenga hijos;
+// devuelve el grupo de procesos de la instancia
+func startGroup(t *testing.T, m *Manager) int {
+	t.Helper()
+	if err := m.StartProgram("group"); err != nil {
+		t.Fatalf("StartProgram() error: %v", err)
+	}
+
+	m.mutex.RLock()
+	pid := m.processes["group"][0].PID
+	m.mutex.RUnlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for liveGroupMembers(pid) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("process group %d never got a child", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return pid
+}
+
+// waitGroupGone da un momento a los procesos que acaban de recibir SIGKILL
+// para terminar y devuelve cuántos siguen vivos
+func waitGroupGone(pgid int) int {
+	deadline := time.Now().Add(time.Second)
+	n := liveGroupMembers(pgid)
+	for n > 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		n = liveGroupMembers(pgid)
+	}
+	return n
+}
+
+func TestShutdownStopsProcessGroup(t *testing.T) {
+	if !procfs.Available() {
+		t.Skip("/proc is not available")
+	}
+
+	tests := []struct {
+		name string
+		cmd  string
+	}{
+		{"children exit with the group", `sh -c 'sleep 100 & wait'`},
+		{"children ignoring the signal are killed", `sh -c '(trap "" TERM; exec sleep 100) & wait'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, "programs:\n  group:\n    cmd: \""+escapeYAML(tt.cmd)+"\"\n    autostart: false\n    starttime: 0\n    stoptime: 5\n")
+			pgid := startGroup(t, m)
+
+			report := m.Shutdown(10 * time.Second)
+
+			if len(report.Clean) != 1 || len(report.Killed) != 0 {
+				t.Errorf("Shutdown() clean = %v, killed = %v, want group_0 clean", report.Clean, report.Killed)
+			}
+			if n := waitGroupGone(pgid); n != 0 {
+				t.Errorf("%d process(es) of group %d still alive after Shutdown()", n, pgid)
+			}
+		})
+	}
+}
+
+func TestStopProgramStopsProcessGroup(t *testing.T) {
+	if !procfs.Available() {
+		t.Skip("/proc is not available")
+	}
+
+	m := newTestManager(t, "programs:\n  group:\n    cmd: \"sh -c 'sleep 100 & wait'\"\n    autostart: false\n    starttime: 0\n    stoptime: 5\n")
+	pgid := startGroup(t, m)
+
+	start := time.Now()
+	if err := m.StopProgram("group"); err != nil {
+		t.Fatalf("StopProgram() error: %v", err)
+	}
+	// Sin hijos huérfanos reteniendo la salida no hay que esperar a outputWaitDelay
+	if elapsed := time.Since(start); elapsed >= outputWaitDelay {
+		t.Errorf("StopProgram() took %s, want less than %s", elapsed, outputWaitDelay)
+	}
+	if n := waitGroupGone(pgid); n != 0 {
+		t.Errorf("%d process(es) of group %d still alive after StopProgram()", n, pgid)
+	}
+}
+
+// escapeYAML escapa un valor para escribirlo entre comillas dobles
+func escapeYAML(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,142 @@
+package process
+
+import (
+	"sort"
+	"time"
+)
+
+// InstanceInfo es una instantánea serializable del estado de una instancia
+type InstanceInfo struct {
+	Program       string         `json:"program"`
+	Name          string         `json:"name"`
+	Index         int            `json:"index"`
+	State         ProcessState   `json:"state"`
+	PID           int            `json:"pid"`
+	StartTime     time.Time      `json:"start_time"`
+	Uptime        float64        `json:"uptime"`
+	ExitCode      int            `json:"exit_code"`
+	RestartCount  int            `json:"restart_count"`
+	RestartReason string         `json:"restart_reason,omitempty"`
+	Usage         *ResourceUsage `json:"usage,omitempty"`
+}
+
+// Snapshot devuelve el estado de todas las instancias ordenado por programa y
+// número de instancia, de modo que prog_2 va antes que prog_10
+func (m *Manager) Snapshot() []InstanceInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	infos := []InstanceInfo{}
+	for programName, instances := range m.processes {
+		for _, instance := range instances {
+			infos = append(infos, m.instanceInfo(programName, instance))
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Program != infos[j].Program {
+			return infos[i].Program < infos[j].Program
+		}
+		return infos[i].Index < infos[j].Index
+	})
+	return infos
+}
+
+// instanceInfo construye la instantánea de una instancia
+func (m *Manager) instanceInfo(programName string, instance *ProcessInstance) InstanceInfo {
+	info := InstanceInfo{
+		Program:       programName,
+		Name:          instance.Name,
+		Index:         instance.Index,
+		State:         instance.State,
+		PID:           instance.PID,
+		StartTime:     instance.StartTime,
+		ExitCode:      instance.ExitCode,
+		RestartCount:  instance.RestartCount,
+		RestartReason: instance.RestartReason,
+		Usage:         instance.Usage,
+	}
+
+	if instance.State == StateRunning && !instance.StartTime.IsZero() {
+		info.Uptime = time.Since(instance.StartTime).Seconds()
+	}
+	if instance.State == StateStopped || instance.State == StateFailed ||
+		instance.State == StateFatal || instance.State == StateBackoff {
+		info.PID = 0
+		info.Usage = nil
+	}
+	return info
+}
+
+// ProgramInfo es una instantánea serializable de un programa configurado y sus instancias
+type ProgramInfo struct {
+	Name      string         `json:"name"`
+	Command   string         `json:"command"`
+	NumProcs  int            `json:"numprocs"`
+	AutoStart bool           `json:"autostart"`
+	Priority  int            `json:"priority"`
+	Instances []InstanceInfo `json:"instances"`
+}
+
+// Programs devuelve todos los programas configurados ordenados por nombre
+func (m *Manager) Programs() []ProgramInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	names := make([]string, 0, len(m.config.Programs))
+	for name := range m.config.Programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	programs := make([]ProgramInfo, 0, len(names))
+	for _, name := range names {
+		programs = append(programs, m.programInfo(name))
+	}
+	return programs
+}
+
+// Program devuelve la instantánea de un programa, o false si no está configurado
+func (m *Manager) Program(name string) (ProgramInfo, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, exists := m.config.Programs[name]; !exists {
+		return ProgramInfo{}, false
+	}
+	return m.programInfo(name), true
+}
+
+// programInfo construye la instantánea de un programa (asume el lock)
+func (m *Manager) programInfo(name string) ProgramInfo {
+	program := m.config.Programs[name]
+	info := ProgramInfo{
+		Name:      name,
+		Command:   program.Cmd,
+		NumProcs:  program.NumProcs,
+		AutoStart: program.AutoStart,
+		Priority:  program.Priority,
+		Instances: []InstanceInfo{},
+	}
+
+	for _, instance := range m.processes[name] {
+		info.Instances = append(info.Instances, m.instanceInfo(name, instance))
+	}
+	sort.Slice(info.Instances, func(i, j int) bool {
+		return info.Instances[i].Index < info.Instances[j].Index
+	})
+	return info
+}
+
+// ProgramNames devuelve los nombres de los programas configurados, ordenados
+func (m *Manager) ProgramNames() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	names := make([]string, 0, len(m.config.Programs))
+	for name := range m.config.Programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"taskmaster/internal/config"
+)
+
+// managerWithInstances crea un gestor con instancias paradas de cada programa,
+// insertadas en orden inverso
+func managerWithInstances(numProcs map[string]int) *Manager {
+	cfg := &config.Config{Programs: map[string]config.Program{}}
+	m := NewManager(cfg, nil)
+	for name, n := range numProcs {
+		cfg.Programs[name] = config.Program{Cmd: "true", NumProcs: n}
+		for i := n - 1; i >= 0; i-- {
+			m.processes[name] = append(m.processes[name], &ProcessInstance{
+				Name:    fmt.Sprintf("%s_%d", name, i),
+				Program: name,
+				Index:   i,
+			})
+		}
+	}
+	return m
+}
+
+func TestSnapshotOrder(t *testing.T) {
+	m := managerWithInstances(map[string]int{"web": 12, "db": 2})
+
+	var got []string
+	for _, info := range m.Snapshot() {
+		got = append(got, info.Name)
+	}
+	want := []string{"db_0", "db_1"}
+	for i := 0; i < 12; i++ {
+		want = append(want, fmt.Sprintf("web_%d", i))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() order = %v, want %v", got, want)
+	}
+
+	program, _ := m.Program("web")
+	got = got[:0]
+	for _, info := range program.Instances {
+		got = append(got, info.Name)
+	}
+	if !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("Program(web) order = %v, want %v", got, want[2:])
+	}
+}
+
+func TestMetricsRestartOrder(t *testing.T) {
+	m := managerWithInstances(nil)
+	m.counters.recordRestart("web", 10, "web_10", RestartReasonExit)
+	m.counters.recordRestart("web", 2, "web_2", RestartReasonMaxRSS)
+	m.counters.recordRestart("web", 2, "web_2", RestartReasonExit)
+	m.counters.recordRestart("api", 3, "api_3", RestartReasonExit)
+
+	var got []string
+	for _, restart := range m.Metrics().Restarts {
+		got = append(got, restart.Instance+"/"+restart.Reason)
+	}
+	want := []string{
+		"api_3/" + RestartReasonExit,
+		"web_2/" + RestartReasonExit,
+		"web_2/" + RestartReasonMaxRSS,
+		"web_10/" + RestartReasonExit,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics().Restarts order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package process
+
+import (
+	"log"
+	"sync/atomic"
+
+	"taskmaster/internal/logger"
+)
+
+// syslogQueueSize es el número de líneas que pueden esperar a syslog antes de
+// empezar a descartarlas
+const syslogQueueSize = 1024
+
+// SyslogOutput reenvía cada línea de salida capturada a syslog con el nombre
+// del programa como etiqueta; stdout se envía como info y stderr como notice.
+// El envío se hace desde una goroutine propia para que un syslog lento o caído
+// no bloquee la salida de los programas
+type SyslogOutput struct {
+	sink     *logger.SyslogSink
+	queue    chan logger.SyslogMessage
+	dropped  atomic.Uint64
+	dropping atomic.Bool
+}
+
+// NewSyslogOutput crea el receptor de salida que escribe en sink
+func NewSyslogOutput(sink *logger.SyslogSink) *SyslogOutput {
+	s := &SyslogOutput{
+		sink:  sink,
+		queue: make(chan logger.SyslogMessage, syslogQueueSize),
+	}
+	go s.run()
+	return s
+}
+
+// BroadcastOutput implementa OutputBroadcaster; si la cola está llena la
+// línea se descarta
+func (s *SyslogOutput) BroadcastOutput(line OutputLine) {
+	severity := logger.SeverityInfo
+	if line.Stream == StreamStderr {
+		severity = logger.SeverityNotice
+	}
+
+	msg := logger.SyslogMessage{
+		Severity: severity,
+		Time:     line.Time,
+		AppName:  line.Program,
+		MsgID:    line.Stream,
+		Params:   map[string]string{"instance": line.Instance},
+		Text:     line.Text,
+	}
+
+	select {
+	case s.queue <- msg:
+		s.dropping.Store(false)
+	default:
+		s.dropped.Add(1)
+		// Se avisa una vez por racha y sin el logger, que también escribe en syslog
+		if !s.dropping.Swap(true) {
+			log.Printf("Syslog queue full, dropping program output")
+		}
+	}
+}
+
+// Dropped devuelve el número de líneas descartadas por tener la cola llena
+func (s *SyslogOutput) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// run envía las líneas encoladas; los errores los informa el propio sink una
+// sola vez
+func (s *SyslogOutput) run() {
+	for msg := range s.queue {
+		s.sink.Send(msg)
+	}
+}
@@ -0,0 +1,68 @@
+package process
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmaster/internal/logger"
+)
+
+func TestSyslogOutputSendsLines(t *testing.T) {
+	address := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", address)
+	if err != nil {
+		t.Fatalf("listen unixgram: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := logger.NewSyslogSink("unix://"+address, 3, "taskmaster")
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	defer sink.Close()
+
+	output := NewSyslogOutput(sink)
+	output.BroadcastOutput(OutputLine{Program: "web", Instance: "web_0", Stream: StreamStdout, Time: time.Now(), Text: "ready"})
+	output.BroadcastOutput(OutputLine{Program: "web", Instance: "web_1", Stream: StreamStderr, Time: time.Now(), Text: "oops"})
+
+	// daemon (3) * 8 + info (6) y notice (5)
+	want := []struct {
+		prefix string
+		suffix string
+	}{
+		{"<30>1 ", ` web - stdout [taskmaster@32473 instance="web_0"] ready`},
+		{"<29>1 ", ` web - stderr [taskmaster@32473 instance="web_1"] oops`},
+	}
+
+	buf := make([]byte, 4096)
+	for _, w := range want {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read syslog message: %v", err)
+		}
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, w.prefix) || !strings.HasSuffix(got, w.suffix) {
+			t.Errorf("received %q, want %q ... %q", got, w.prefix, w.suffix)
+		}
+	}
+}
+
+func TestSyslogOutputDropsWhenFull(t *testing.T) {
+	// Sin la goroutine de envío la cola no se vacía, como con un syslog bloqueado
+	output := &SyslogOutput{queue: make(chan logger.SyslogMessage, 2)}
+
+	for i := 0; i < 5; i++ {
+		output.BroadcastOutput(OutputLine{Program: "web", Stream: StreamStdout, Text: "line"})
+	}
+
+	if got := len(output.queue); got != 2 {
+		t.Errorf("queued %d lines, want 2", got)
+	}
+	if got := output.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+}
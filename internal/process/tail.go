@@ -0,0 +1,363 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tailBufferLines es el número de líneas recientes que se guardan por flujo e instancia
+const tailBufferLines = 1000
+
+// maxLineLength corta las líneas sin salto de línea para acotar la memoria
+const maxLineLength = 4096
+
+// followBuffer es la capacidad del canal de un seguidor; si se llena se descartan líneas
+const followBuffer = 256
+
+// Flujos de salida de un proceso
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputLine es una línea de salida capturada de una instancia
+type OutputLine struct {
+	Program  string    `json:"program"`
+	Instance string    `json:"instance"`
+	Stream   string    `json:"stream"`
+	Time     time.Time `json:"time"`
+	Text     string    `json:"text"`
+	Seq      uint64    `json:"seq"` // posición en el flujo de la instancia, creciente
+}
+
+// OutputBroadcaster es la interfaz para enviar cada línea de salida capturada
+type OutputBroadcaster interface {
+	BroadcastOutput(line OutputLine)
+}
+
+// AddOutputBroadcaster añade un receptor de la salida de los procesos; se debe
+// llamar antes de arrancar los programas
+func (m *Manager) AddOutputBroadcaster(broadcaster OutputBroadcaster) {
+	m.outputBroadcasters = append(m.outputBroadcasters, broadcaster)
+}
+
+// lineRing guarda las últimas líneas de un flujo
+type lineRing struct {
+	mutex sync.Mutex
+	lines []OutputLine
+	next  int
+	seq   uint64 // número de la última línea añadida
+}
+
+// add numera la línea, la guarda y la devuelve con su número
+func (r *lineRing) add(line OutputLine) OutputLine {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seq++
+	line.Seq = r.seq
+	if len(r.lines) < tailBufferLines {
+		r.lines = append(r.lines, line)
+		return line
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % tailBufferLines
+	return line
+}
+
+// last devuelve como mucho las n líneas más recientes, de la más antigua a la más nueva
+func (r *lineRing) last(n int) []OutputLine {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ordered := append(append([]OutputLine{}, r.lines[r.next:]...), r.lines[:r.next]...)
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// follower recibe las líneas nuevas de un programa o de una de sus instancias
+type follower struct {
+	program  string
+	instance string // vacío para todas las instancias
+	stream   string
+	lines    chan OutputLine
+}
+
+// tailBuffers guarda los anillos de salida por instancia, de modo que sobreviven
+// a los reinicios, y reparte las líneas nuevas entre los seguidores
+type tailBuffers struct {
+	mutex     sync.RWMutex
+	rings     map[string]*lineRing
+	followers map[*follower]bool
+}
+
+func ringKey(instance, stream string) string {
+	return instance + "/" + stream
+}
+
+// ring devuelve el anillo de un flujo de una instancia, creándolo si no existe
+func (t *tailBuffers) ring(instance, stream string) *lineRing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.rings == nil {
+		t.rings = make(map[string]*lineRing)
+	}
+	key := ringKey(instance, stream)
+	if ring, exists := t.rings[key]; exists {
+		return ring
+	}
+	ring := &lineRing{}
+	t.rings[key] = ring
+	return ring
+}
+
+// lines devuelve las últimas n líneas de un flujo de una instancia
+func (t *tailBuffers) lines(instance, stream string, n int) []OutputLine {
+	t.mutex.RLock()
+	ring, exists := t.rings[ringKey(instance, stream)]
+	t.mutex.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	return ring.last(n)
+}
+
+func (t *tailBuffers) follow(program, instance, stream string) *follower {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.followers == nil {
+		t.followers = make(map[*follower]bool)
+	}
+	f := &follower{program: program, instance: instance, stream: stream, lines: make(chan OutputLine, followBuffer)}
+	t.followers[f] = true
+	return f
+}
+
+func (t *tailBuffers) unfollow(f *follower) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.followers[f] {
+		delete(t.followers, f)
+		close(f.lines)
+	}
+}
+
+// publish envía una línea a los seguidores interesados sin bloquear nunca
+func (t *tailBuffers) publish(line OutputLine) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for f := range t.followers {
+		if f.program != line.Program || f.stream != line.Stream {
+			continue
+		}
+		if f.instance != "" && f.instance != line.Instance {
+			continue
+		}
+		select {
+		case f.lines <- line:
+		default:
+		}
+	}
+}
+
+// tailWriter parte la salida de un flujo en líneas, las guarda en el anillo de
+// la instancia y las publica
+type tailWriter struct {
+	manager  *Manager
+	ring     *lineRing
+	program  string
+	instance string
+	stream   string
+	partial  []byte
+}
+
+func (m *Manager) newTailWriter(instance *ProcessInstance, stream string) *tailWriter {
+	return &tailWriter{
+		manager:  m,
+		ring:     m.tails.ring(instance.Name, stream),
+		program:  instance.Program,
+		instance: instance.Name,
+		stream:   stream,
+	}
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+
+	for {
+		pos := bytes.IndexByte(w.partial, '\n')
+		if pos < 0 {
+			break
+		}
+		w.emit(w.partial[:pos])
+		w.partial = w.partial[pos+1:]
+	}
+
+	for len(w.partial) >= maxLineLength {
+		w.emit(w.partial[:maxLineLength])
+		w.partial = w.partial[maxLineLength:]
+	}
+
+	// Compactar para no retener el array original
+	w.partial = append([]byte(nil), w.partial...)
+	return len(p), nil
+}
+
+// flush emite la última línea si el proceso terminó sin salto de línea
+func (w *tailWriter) flush() {
+	if len(w.partial) > 0 {
+		w.emit(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *tailWriter) emit(text []byte) {
+	line := OutputLine{
+		Program:  w.program,
+		Instance: w.instance,
+		Stream:   w.stream,
+		Time:     time.Now(),
+		Text:     string(bytes.TrimSuffix(text, []byte("\r"))),
+	}
+
+	line = w.ring.add(line)
+	w.manager.tails.publish(line)
+	for _, broadcaster := range w.manager.outputBroadcasters {
+		broadcaster.BroadcastOutput(line)
+	}
+}
+
+// resolveTailTarget traduce un programa o una instancia al programa y a los
+// nombres de instancia cuya salida se debe mostrar
+func (m *Manager) resolveTailTarget(target, stream string) (string, []string, error) {
+	if stream != StreamStdout && stream != StreamStderr {
+		return "", nil, fmt.Errorf("unknown stream %s (use stdout or stderr)", stream)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if program, exists := m.config.Programs[target]; exists {
+		names := make([]string, program.NumProcs)
+		for i := range names {
+			names[i] = instanceName(target, i)
+		}
+		return target, names, nil
+	}
+
+	name, index, err := m.resolveInstance(target)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, []string{instanceName(name, index)}, nil
+}
+
+// Tail devuelve las últimas n líneas de stdout o stderr de un programa (todas
+// sus instancias, mezcladas por orden de llegada) o de una sola instancia
+func (m *Manager) Tail(target, stream string, n int) ([]OutputLine, error) {
+	_, names, err := m.resolveTailTarget(target, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []OutputLine
+	for _, name := range names {
+		lines = append(lines, m.tails.lines(name, stream, n)...)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time.Before(lines[j].Time)
+	})
+	if n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// FollowOutput devuelve un canal con las nuevas líneas de un programa o de una
+// instancia y una función para dejar de seguirlas, que cierra el canal
+func (m *Manager) FollowOutput(target, stream string) (<-chan OutputLine, func(), error) {
+	program, names, err := m.resolveTailTarget(target, stream)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instance := ""
+	if target != program {
+		instance = names[0]
+	}
+
+	f := m.tails.follow(program, instance, stream)
+	return f.lines, func() { m.tails.unfollow(f) }, nil
+}
+
+// OutputCursor recuerda la última línea entregada de cada instancia. Al pasar
+// del buffer al seguimiento algunas líneas llegan dos veces; las instancias
+// numeran sus propias líneas, así que la hora no sirve para distinguirlas.
+type OutputCursor map[string]uint64
+
+// Advance indica si la línea aún no se entregó y, en ese caso, la marca
+func (c OutputCursor) Advance(line OutputLine) bool {
+	if line.Seq <= c[line.Instance] {
+		return false
+	}
+	c[line.Instance] = line.Seq
+	return true
+}
+
+// defaultTailLines es el número de líneas que muestra tail si no se indica -n
+const defaultTailLines = 10
+
+// TailRequest son los argumentos de tail <objetivo> [stdout|stderr] [-n N] [-f]
+type TailRequest struct {
+	Target string
+	Stream string
+	Lines  int
+	Follow bool
+}
+
+// ParseTailArgs interpreta los argumentos del comando tail
+func ParseTailArgs(args []string) (TailRequest, error) {
+	req := TailRequest{Stream: StreamStdout, Lines: defaultTailLines}
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-f":
+			req.Follow = true
+		case arg == "-n":
+			if i+1 >= len(args) {
+				return req, fmt.Errorf("-n requires a number of lines")
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return req, fmt.Errorf("invalid number of lines: %s", args[i])
+			}
+			req.Lines = n
+		case arg == StreamStdout || arg == StreamStderr:
+			req.Stream = arg
+		case strings.HasPrefix(arg, "-"):
+			return req, fmt.Errorf("unknown tail option: %s", arg)
+		case req.Target == "":
+			req.Target = arg
+		default:
+			return req, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+
+	if req.Target == "" {
+		return req, fmt.Errorf("tail requires a program or instance name")
+	}
+	return req, nil
+}
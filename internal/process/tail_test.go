@@ -0,0 +1,82 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutputCursor(t *testing.T) {
+	now := time.Now()
+	var a, b lineRing
+	a1 := a.add(OutputLine{Instance: "web_0", Time: now, Text: "a1"})
+	b1 := b.add(OutputLine{Instance: "web_1", Time: now, Text: "b1"})
+	a2 := a.add(OutputLine{Instance: "web_0", Time: now, Text: "a2"})
+
+	// El buffer ya entregó a1; el seguimiento repite a1 y trae el resto
+	cursor := make(OutputCursor)
+	cursor.Advance(a1)
+
+	tests := []struct {
+		line OutputLine
+		want bool
+	}{
+		{a1, false},
+		{b1, true}, // misma hora, otra instancia
+		{a2, true},
+		{b1, false},
+		{a2, false},
+	}
+	for _, tt := range tests {
+		if got := cursor.Advance(tt.line); got != tt.want {
+			t.Errorf("Advance(%s #%d) = %v, want %v", tt.line.Text, tt.line.Seq, got, tt.want)
+		}
+	}
+}
+
+func TestLineRingLast(t *testing.T) {
+	var ring lineRing
+	for i := 0; i < tailBufferLines+5; i++ {
+		ring.add(OutputLine{})
+	}
+
+	tests := []struct {
+		n         int
+		wantLen   int
+		wantFirst uint64
+	}{
+		{3, 3, tailBufferLines + 3},
+		{tailBufferLines * 2, tailBufferLines, 6},
+	}
+	for _, tt := range tests {
+		lines := ring.last(tt.n)
+		if len(lines) != tt.wantLen || lines[0].Seq != tt.wantFirst || lines[len(lines)-1].Seq != tailBufferLines+5 {
+			t.Errorf("last(%d) = %d lines from #%d to #%d, want %d from #%d to #%d", tt.n, len(lines),
+				lines[0].Seq, lines[len(lines)-1].Seq, tt.wantLen, tt.wantFirst, tailBufferLines+5)
+		}
+	}
+}
+
+func TestParseTailArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    TailRequest
+		wantErr bool
+	}{
+		{[]string{"web"}, TailRequest{Target: "web", Stream: StreamStdout, Lines: defaultTailLines}, false},
+		{[]string{"web_1", "stderr", "-n", "5", "-f"}, TailRequest{Target: "web_1", Stream: StreamStderr, Lines: 5, Follow: true}, false},
+		{[]string{}, TailRequest{}, true},
+		{[]string{"web", "-n", "0"}, TailRequest{}, true},
+		{[]string{"web", "-x"}, TailRequest{}, true},
+		{[]string{"web", "api"}, TailRequest{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTailArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTailArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseTailArgs(%v) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
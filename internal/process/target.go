@@ -0,0 +1,266 @@
+package process
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"taskmaster/pkg/signals"
+)
+
+// instanceName construye el nombre de la instancia index de un programa
+func instanceName(program string, index int) string {
+	return fmt.Sprintf("%s_%d", program, index)
+}
+
+// IsProgram indica si un objetivo es el nombre de un programa configurado
+func (m *Manager) IsProgram(target string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, exists := m.config.Programs[target]
+	return exists
+}
+
+// TargetProgram devuelve el programa de un objetivo, que puede ser un programa
+// o una de sus instancias
+func (m *Manager) TargetProgram(target string) (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, exists := m.config.Programs[target]; exists {
+		return target, nil
+	}
+	name, _, err := m.resolveInstance(target)
+	return name, err
+}
+
+// resolveInstance traduce "programa:N" o "programa_N" a programa e índice (asume el lock)
+func (m *Manager) resolveInstance(target string) (string, int, error) {
+	for _, sep := range []string{":", "_"} {
+		pos := strings.LastIndex(target, sep)
+		if pos <= 0 {
+			continue
+		}
+
+		name := target[:pos]
+		program, exists := m.config.Programs[name]
+		if !exists {
+			continue
+		}
+
+		index, err := strconv.Atoi(target[pos+1:])
+		if err != nil || index < 0 {
+			continue
+		}
+		if index >= program.NumProcs {
+			return "", 0, fmt.Errorf("program %s has only %d instance(s)", name, program.NumProcs)
+		}
+		return name, index, nil
+	}
+
+	return "", 0, fmt.Errorf("unknown program or instance: %s", target)
+}
+
+// findInstance busca la instancia index de un programa (asume el lock)
+func (m *Manager) findInstance(name string, index int) *ProcessInstance {
+	for _, instance := range m.processes[name] {
+		if instance.Index == index {
+			return instance
+		}
+	}
+	return nil
+}
+
+// removeInstance elimina del registro la instancia index de un programa (asume el lock)
+func (m *Manager) removeInstance(name string, index int) {
+	instances := m.processes[name]
+	for i, instance := range instances {
+		if instance.Index == index {
+			m.processes[name] = append(instances[:i:i], instances[i+1:]...)
+			return
+		}
+	}
+}
+
+// StartInstance inicia una única instancia sin tocar a sus hermanas
+func (m *Manager) StartInstance(target string) error {
+	m.mutex.RLock()
+	name, _, err := m.resolveInstance(target)
+	m.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := m.waitForDependencies(name); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.startInstanceUnsafe(target)
+}
+
+// startInstanceUnsafe inicia una única instancia (asume el lock)
+func (m *Manager) startInstanceUnsafe(target string) error {
+	name, index, err := m.resolveInstance(target)
+	if err != nil {
+		return err
+	}
+
+	program := m.config.Programs[name]
+	if err := m.checkDependenciesActive(name, program); err != nil {
+		return err
+	}
+
+	instance := m.findInstance(name, index)
+	if instance == nil {
+		return m.startNewInstance(name, index, m.programProcessConfig(name, program))
+	}
+
+	if m.isActiveInstance(instance) {
+		return fmt.Errorf("instance %s is already %s", instance.Name, instance.State)
+	}
+
+	instance.RestartCount = 0
+	instance.overLimit = false
+	if err := m.startProcessInstance(instance, name); err != nil {
+		instance.State = StateFailed
+		return fmt.Errorf("failed to start instance %s: %w", instance.Name, err)
+	}
+
+	m.instanceLog(instance, EventProcessStarted).Info("Started process %s (PID: %d), STARTING for %ds",
+		instance.Name, instance.PID, instance.Config.StartTime)
+	return nil
+}
+
+// StopInstance detiene una única instancia sin tocar a sus hermanas
+func (m *Manager) StopInstance(target string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stopInstanceUnsafe(target)
+}
+
+// stopInstanceUnsafe detiene una única instancia (asume el lock)
+func (m *Manager) stopInstanceUnsafe(target string) error {
+	name, index, err := m.resolveInstance(target)
+	if err != nil {
+		return err
+	}
+
+	instance := m.findInstance(name, index)
+	if instance == nil || !m.stopProcessInstance(instance) {
+		return fmt.Errorf("instance %s is not running", instanceName(name, index))
+	}
+	return nil
+}
+
+// RestartInstance reinicia una única instancia sin tocar a sus hermanas
+func (m *Manager) RestartInstance(target string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name, index, err := m.resolveInstance(target)
+	if err != nil {
+		return err
+	}
+
+	if instance := m.findInstance(name, index); instance != nil && m.isActiveInstance(instance) {
+		m.stopProcessInstance(instance)
+	}
+
+	// Como StartInstance, no arranca hasta que sus dependencias estén RUNNING
+	if program, exists := m.config.Programs[name]; exists && len(program.DependsOn) > 0 {
+		m.mutex.Unlock()
+		err := m.waitForDependencies(name)
+		m.mutex.Lock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.startInstanceUnsafe(target)
+}
+
+// SignalInstance envía una señal a una única instancia
+func (m *Manager) SignalInstance(target, signalName string) error {
+	if !signals.IsValidSignal(signalName) {
+		return fmt.Errorf("unknown signal: %s", signalName)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	name, index, err := m.resolveInstance(target)
+	if err != nil {
+		return err
+	}
+
+	instance := m.findInstance(name, index)
+	if instance == nil || !m.isAliveInstance(instance) {
+		return fmt.Errorf("instance %s is not running", instanceName(name, index))
+	}
+
+	m.instanceLog(instance, EventProcessSignaled).Info("Sending signal %s to process %s (PID %d)", signalName, instance.Name, instance.PID)
+	return signals.SendSignal(instance.Cmd.Process, signalName)
+}
+
+// SignalProgram envía una señal a todas las instancias vivas de un programa
+func (m *Manager) SignalProgram(name, signalName string) error {
+	if !signals.IsValidSignal(signalName) {
+		return fmt.Errorf("unknown signal: %s", signalName)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, exists := m.config.Programs[name]; !exists {
+		return fmt.Errorf("program %s not found in configuration", name)
+	}
+
+	alive := m.aliveInstances(m.processes[name])
+	if len(alive) == 0 {
+		return fmt.Errorf("program %s is not running", name)
+	}
+
+	for _, instance := range alive {
+		m.instanceLog(instance, EventProcessSignaled).Info("Sending signal %s to process %s (PID %d)", signalName, instance.Name, instance.PID)
+		if err := signals.SendSignal(instance.Cmd.Process, signalName); err != nil {
+			return fmt.Errorf("failed to signal %s: %w", instance.Name, err)
+		}
+	}
+	return nil
+}
+
+// StartTarget inicia un programa completo o una sola instancia según el objetivo
+func (m *Manager) StartTarget(target string) error {
+	if m.IsProgram(target) {
+		return m.StartProgram(target)
+	}
+	return m.StartInstance(target)
+}
+
+// StopTarget detiene un programa completo o una sola instancia según el objetivo
+func (m *Manager) StopTarget(target string) error {
+	if m.IsProgram(target) {
+		return m.StopProgram(target)
+	}
+	return m.StopInstance(target)
+}
+
+// RestartTarget reinicia un programa completo o una sola instancia según el objetivo
+func (m *Manager) RestartTarget(target string) error {
+	if m.IsProgram(target) {
+		return m.RestartProgram(target)
+	}
+	return m.RestartInstance(target)
+}
+
+// SignalTarget envía una señal a un programa completo o a una sola instancia
+func (m *Manager) SignalTarget(target, signalName string) error {
+	if m.IsProgram(target) {
+		return m.SignalProgram(target, signalName)
+	}
+	return m.SignalInstance(target, signalName)
+}
@@ -0,0 +1,37 @@
+package process
+
+import "testing"
+
+func TestRestartInstanceWaitsForDependencies(t *testing.T) {
+	m := newTestManager(t, `programs:
+  db:
+    cmd: "sleep 100"
+    autostart: false
+    starttime: 1
+  app:
+    cmd: "sleep 100"
+    autostart: false
+    starttime: 0
+    depends_on: [db]
+`)
+	if err := m.StartInstance("db_0"); err != nil {
+		t.Fatalf("StartInstance(db_0) error: %v", err)
+	}
+	if err := m.StartInstance("app_0"); err != nil {
+		t.Fatalf("StartInstance(app_0) error: %v", err)
+	}
+
+	// db vuelve a STARTING: app no debe arrancar hasta que pase starttime
+	if err := m.RestartInstance("db_0"); err != nil {
+		t.Fatalf("RestartInstance(db_0) error: %v", err)
+	}
+	if err := m.RestartInstance("app_0"); err != nil {
+		t.Fatalf("RestartInstance(app_0) error: %v", err)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if state := m.processes["db"][0].State; state != StateRunning {
+		t.Errorf("app_0 restarted while db_0 was %s, want RUNNING", state)
+	}
+}
@@ -1,9 +1,14 @@
 package process
 
 import (
+	"encoding/json"
+	"fmt"
 	"os/exec"
 	"sync"
+	"sync/atomic"
+	"taskmaster/internal/cgroup"
 	"taskmaster/internal/config"
+	"taskmaster/internal/logfile"
 	"taskmaster/internal/logger"
 	"time"
 )
@@ -20,11 +25,22 @@ type Manager struct {
 	logger      *logger.Logger
 	mutex       sync.RWMutex
 	broadcaster StatusBroadcaster
+	shuttingDown atomic.Bool
+	reloadMutex sync.Mutex // serializa las recargas, que verifican sin el lock principal
+	outputs     outputFiles
+	tails       tailBuffers
+	outputBroadcasters []OutputBroadcaster
+	counters    processCounters
+	startTime   time.Time
+	cgroups     *cgroup.Hierarchy
+	cgroupErrors map[string]string // último error de configuración del cgroup de cada programa
 }
 
 // ProcessInstance representa una instancia específica de un proceso
 type ProcessInstance struct {
 	Name         string       `json:"name"`
+	Program      string       `json:"program"`
+	Index        int          `json:"index"`
 	Config       *ProcessConfig `json:"-"`
 	Cmd          *exec.Cmd    `json:"-"`
 	PID          int          `json:"pid"`
@@ -34,6 +50,13 @@ type ProcessInstance struct {
 	RestartCount int          `json:"restart_count"`
 	StopChan     chan bool    `json:"-"`
 	ManualStop   bool         `json:"manual_stop"`
+	Usage        *ResourceUsage `json:"usage,omitempty"` // última muestra de /proc, nil si no corre
+	RestartReason string      `json:"restart_reason,omitempty"` // motivo del último reinicio automático
+	limitRestart bool         // el watchdog la está deteniendo para reiniciarla
+	overLimit    bool         // reiniciada por un límite y sin una muestra dentro de ellos desde entonces
+	exited       chan struct{}
+	output       *outputRun // captura de salida de la ejecución actual
+	cgroup       *cgroup.Group // cgroup de la instancia, nil sin cgroup v2
 }
 
 // ProcessState representa el estado actual de un proceso
@@ -45,6 +68,8 @@ const (
 	StateRunning
 	StateFailed
 	StateRestarting
+	StateBackoff
+	StateFatal
 )
 
 // String convierte ProcessState a string legible
@@ -55,6 +80,8 @@ func (s ProcessState) String() string {
 		StateRunning:    "RUNNING",
 		StateFailed:     "FAILED",
 		StateRestarting: "RESTARTING",
+		StateBackoff:    "BACKOFF",
+		StateFatal:      "FATAL",
 	}
 	if state, exists := states[s]; exists {
 		return state
@@ -67,6 +94,21 @@ func (s ProcessState) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + s.String() + `"`), nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler interface to parse ProcessState from its string form
+func (s *ProcessState) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for state := StateStopped; state.String() != "UNKNOWN"; state++ {
+		if state.String() == name {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown process state: %s", name)
+}
+
 // ProcessConfig contiene la configuración de un proceso
 type ProcessConfig struct {
 	Cmd          string
@@ -83,4 +125,24 @@ type ProcessConfig struct {
 	Env          map[string]string
 	WorkingDir   string
 	Umask        string
+	Priority     int
+	User         string
+	Group        string
+	Groups       []string
+
+	BackoffInitial    int
+	BackoffMax        int
+	BackoffMultiplier float64
+	BackoffJitter     float64
+
+	StdoutLog logfile.Options
+	StderrLog logfile.Options
+
+	MaxRSS        int64
+	MaxCPUPercent float64
+	MaxCPUWindow  int
+	MaxOpenFiles  int
+
+	Rlimits config.Rlimits
+	Cgroup  cgroup.Limits
 }
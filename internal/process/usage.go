@@ -0,0 +1,168 @@
+package process
+
+import (
+	"time"
+
+	"taskmaster/internal/procfs"
+)
+
+// DefaultSampleInterval es el intervalo por defecto entre muestras de /proc
+const DefaultSampleInterval = 2 * time.Second
+
+// ResourceUsage es el consumo de recursos de una instancia y de los procesos
+// de su grupo (los hijos que lanza el comando a través de sh -c)
+type ResourceUsage struct {
+	Time       time.Time `json:"time"`
+	Processes  int       `json:"processes"`   // procesos en el grupo
+	RSS        int64     `json:"rss_bytes"`   // memoria residente
+	CPUPercent float64   `json:"cpu_percent"` // 100 = un núcleo completo
+	Threads    int       `json:"threads"`
+	OpenFDs    int       `json:"open_fds"`
+	ReadBytes  uint64    `json:"read_bytes"`  // leídos de almacenamiento
+	WriteBytes uint64    `json:"write_bytes"` // escritos a almacenamiento
+
+	pid          int       // PID de la ejecución muestreada
+	cpuTicks     uint64    // tiempo de CPU acumulado, para calcular el porcentaje
+	cpuOverSince time.Time // desde cuándo se supera max_cpu_percent
+}
+
+// StartResourceSampler muestrea periódicamente /proc para cada instancia en
+// ejecución; con un intervalo <= 0 o sin /proc no hace nada
+func (m *Manager) StartResourceSampler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	if !procfs.Available() {
+		m.logger.Warn("⚠️  %s is not available, resource sampling disabled", procfs.Root)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if m.shuttingDown.Load() {
+				return
+			}
+			m.sampleResources()
+		}
+	}()
+}
+
+// sampleTarget es una instancia a muestrear junto con su muestra anterior
+type sampleTarget struct {
+	instance  *ProcessInstance
+	pid       int
+	startTime time.Time
+	previous  *ResourceUsage
+}
+
+// sampleResources lee /proc sin el lock, que solo se toma para elegir las
+// instancias y para guardar los resultados
+func (m *Manager) sampleResources() {
+	var targets []sampleTarget
+	m.mutex.RLock()
+	for _, instances := range m.processes {
+		for _, instance := range instances {
+			if instance.PID > 0 && (instance.State == StateRunning || instance.State == StateStarting) {
+				targets = append(targets, sampleTarget{instance, instance.PID, instance.StartTime, instance.Usage})
+			}
+		}
+	}
+	m.mutex.RUnlock()
+
+	results := make(map[*ProcessInstance]*ResourceUsage, len(targets))
+	if len(targets) > 0 {
+		stats, err := procfs.ReadAllStats()
+		if err != nil {
+			m.logger.Debug("Failed to read %s: %v", procfs.Root, err)
+			return
+		}
+
+		// Cada instancia lidera su propio grupo de procesos (Setpgid)
+		groups := make(map[int][]procfs.Stat)
+		for _, stat := range stats {
+			groups[stat.PGRP] = append(groups[stat.PGRP], stat)
+		}
+
+		now := time.Now()
+		for _, target := range targets {
+			if members := groups[target.pid]; len(members) > 0 {
+				results[target.instance] = measureGroup(target, members, now)
+			}
+		}
+	}
+
+	var breaches []*limitBreach
+	m.mutex.Lock()
+	for _, instances := range m.processes {
+		for _, instance := range instances {
+			usage := results[instance]
+			if usage != nil && usage.pid != instance.PID {
+				// La instancia se reinició mientras se leía /proc
+				usage = nil
+			}
+			previous := instance.Usage
+			instance.Usage = usage
+			if breach := m.checkLimits(instance, previous); breach != nil {
+				breaches = append(breaches, breach)
+			} else if instance.overLimit && usage != nil && instance.State == StateRunning && usage.cpuOverSince.IsZero() {
+				// Vuelve a estar dentro de sus límites: cuenta como recuperada
+				instance.overLimit = false
+				instance.RestartCount = 0
+			}
+		}
+	}
+	if len(targets) > 0 {
+		m.broadcastStatus()
+	}
+	m.mutex.Unlock()
+
+	// Detener una instancia espera hasta su stoptime: cada reinicio va aparte
+	for _, breach := range breaches {
+		go m.restartOverLimit(breach)
+	}
+}
+
+// measureGroup suma el consumo de los procesos de un grupo
+func measureGroup(target sampleTarget, members []procfs.Stat, now time.Time) *ResourceUsage {
+	usage := &ResourceUsage{
+		Time:      now,
+		Processes: len(members),
+		pid:       target.pid,
+	}
+
+	for _, member := range members {
+		usage.Threads += member.Threads
+		usage.cpuTicks += member.UTime + member.STime
+
+		// Los procesos pueden terminar entre lecturas y /proc/<pid>/io solo es
+		// legible para el mismo usuario, así que los errores se ignoran
+		if rss, err := procfs.ReadRSS(member.PID); err == nil {
+			usage.RSS += rss
+		}
+		if fds, err := procfs.CountFDs(member.PID); err == nil {
+			usage.OpenFDs += fds
+		}
+		if io, err := procfs.ReadIO(member.PID); err == nil {
+			usage.ReadBytes += io.ReadBytes
+			usage.WriteBytes += io.WriteBytes
+		}
+	}
+
+	// Con una muestra anterior de la misma ejecución se usa la diferencia; en
+	// la primera, la media desde el arranque
+	previousTicks, since := uint64(0), target.startTime
+	if target.previous != nil && target.previous.pid == target.pid {
+		previousTicks, since = target.previous.cpuTicks, target.previous.Time
+	}
+	elapsed := now.Sub(since).Seconds()
+	// Los hijos que terminan se llevan su tiempo de CPU, así que el total
+	// del grupo puede bajar
+	if elapsed > 0 && usage.cpuTicks > previousTicks {
+		cpuSeconds := float64(usage.cpuTicks-previousTicks) / procfs.ClockTicks
+		usage.CPUPercent = cpuSeconds / elapsed * 100
+	}
+	return usage
+}
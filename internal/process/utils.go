@@ -33,7 +33,8 @@ func (m *Manager) cleanupProgramUnsafe(name string) int {
 func (m *Manager) isActiveInstance(instance *ProcessInstance) bool {
 	return instance.State == StateRunning ||
 		instance.State == StateStarting ||
-		instance.State == StateRestarting
+		instance.State == StateRestarting ||
+		instance.State == StateBackoff
 }
 
 // countActiveInstances cuenta las instancias activas en una lista
@@ -0,0 +1,122 @@
+package process
+
+import (
+	"fmt"
+	"time"
+
+	"taskmaster/internal/config"
+)
+
+// Motivos de reinicio automático: una salida del proceso o el límite de
+// recursos que superó
+const (
+	RestartReasonExit         = "exit"
+	RestartReasonMaxRSS       = "max_rss"
+	RestartReasonMaxCPU       = "max_cpu_percent"
+	RestartReasonMaxOpenFiles = "max_open_files"
+)
+
+// limitBreach es un límite superado por una instancia en una muestra
+type limitBreach struct {
+	instance *ProcessInstance
+	pid      int
+	reason   string
+	detail   string
+}
+
+// checkLimits compara la última muestra de una instancia RUNNING con sus
+// límites; previous es la muestra anterior, de la que se hereda desde cuándo
+// se supera max_cpu_percent (asume el lock)
+func (m *Manager) checkLimits(instance *ProcessInstance, previous *ResourceUsage) *limitBreach {
+	usage := instance.Usage
+	limits := instance.Config
+	if usage == nil || instance.State != StateRunning {
+		return nil
+	}
+
+	if limits.MaxCPUPercent > 0 && usage.CPUPercent > limits.MaxCPUPercent {
+		usage.cpuOverSince = usage.Time
+		if previous != nil && previous.pid == usage.pid && !previous.cpuOverSince.IsZero() {
+			usage.cpuOverSince = previous.cpuOverSince
+		}
+	}
+
+	breach := &limitBreach{instance: instance, pid: usage.pid}
+	window := time.Duration(limits.MaxCPUWindow) * time.Second
+	switch {
+	case limits.MaxRSS > 0 && usage.RSS > limits.MaxRSS:
+		breach.reason = RestartReasonMaxRSS
+		breach.detail = fmt.Sprintf("RSS %s > %s", config.ByteSize(usage.RSS), config.ByteSize(limits.MaxRSS))
+	case limits.MaxOpenFiles > 0 && usage.OpenFDs > limits.MaxOpenFiles:
+		breach.reason = RestartReasonMaxOpenFiles
+		breach.detail = fmt.Sprintf("%d open files > %d", usage.OpenFDs, limits.MaxOpenFiles)
+	case !usage.cpuOverSince.IsZero() && usage.Time.Sub(usage.cpuOverSince) >= window:
+		breach.reason = RestartReasonMaxCPU
+		breach.detail = fmt.Sprintf("CPU %.1f%% > %g%% for %s", usage.CPUPercent, limits.MaxCPUPercent,
+			usage.Time.Sub(usage.cpuOverSince).Round(time.Second))
+	default:
+		return nil
+	}
+	return breach
+}
+
+// restartOverLimit reinicia una instancia que superó un límite deteniéndola
+// como un stop manual y arrancándola tras su backoff, como tras una salida.
+// Estos reinicios cuentan para startretries: un programa que supera el límite
+// nada más arrancar acaba en FATAL. La parada puede durar stoptime y se hace
+// sin el lock para que el resto del supervisor siga respondiendo
+func (m *Manager) restartOverLimit(breach *limitBreach) {
+	m.mutex.Lock()
+	instance := breach.instance
+	// La instancia pudo reiniciarse, detenerse o eliminarse mientras tanto
+	if m.shuttingDown.Load() || instance.PID != breach.pid || instance.State != StateRunning ||
+		instance.limitRestart || m.findInstance(instance.Program, instance.Index) != instance {
+		m.mutex.Unlock()
+		return
+	}
+
+	m.instanceLog(instance, EventProcessOverLimit).Warn("Process %s exceeded %s (%s), restarting",
+		instance.Name, breach.reason, breach.detail)
+	m.instanceLog(instance, EventProcessStopping).Info("Stopping process %s with signal %s (timeout: %ds)",
+		instance.Name, instance.Config.StopSignal, instance.Config.StopTime)
+
+	instance.limitRestart = true
+	stop := m.prepareStop(instance)
+	stopTimeout := time.Duration(instance.Config.StopTime) * time.Second
+	m.mutex.Unlock()
+
+	m.stopRun(stop, stopTimeout)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Un stop, un restart o una recarga pudieron ocuparse de la instancia
+	// durante la espera: entonces el reinicio ya no nos corresponde
+	if !instance.limitRestart || instance.exited != stop.exited || m.shuttingDown.Load() ||
+		m.findInstance(instance.Program, instance.Index) != instance {
+		return
+	}
+	instance.limitRestart = false
+
+	select {
+	case <-stop.exited:
+	default:
+		m.logger.Error("Process %s (PID %d) was not reaped, not restarting it", instance.Name, breach.pid)
+		return
+	}
+
+	instance.overLimit = true
+	if instance.RestartCount >= instance.Config.StartRetries {
+		m.markFatal(instance)
+		return
+	}
+
+	// La parada la pidió el watchdog: el reinicio pendiente sólo lo cancela
+	// un stop posterior
+	instance.ManualStop = false
+	select {
+	case <-instance.StopChan:
+	default:
+	}
+	m.attemptRestart(instance, instance.Program, breach.reason)
+}
@@ -0,0 +1,56 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"taskmaster/internal/procfs"
+)
+
+func TestOverLimitRestartsCountTowardStartRetries(t *testing.T) {
+	if !procfs.Available() {
+		t.Skip("/proc is not available")
+	}
+
+	m := newTestManager(t, `programs:
+  hog:
+    cmd: "sleep 100"
+    autostart: false
+    starttime: 0
+    stoptime: 1
+    startretries: 1
+    backoff_initial: 1
+    backoff_jitter: 0
+    max_rss: 1KB
+`)
+	if err := m.StartProgram("hog"); err != nil {
+		t.Fatalf("StartProgram() error: %v", err)
+	}
+	m.StartResourceSampler(50 * time.Millisecond)
+
+	// Cada arranque supera max_rss: tras un reinicio se agota startretries
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		m.mutex.RLock()
+		state := m.processes["hog"][0].State
+		m.mutex.RUnlock()
+		if state == StateFatal {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("instance state = %s, want FATAL", state)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var restarts uint64
+	for _, restart := range m.Metrics().Restarts {
+		if restart.Reason != RestartReasonMaxRSS {
+			t.Errorf("restart recorded with reason %s, want %s", restart.Reason, RestartReasonMaxRSS)
+		}
+		restarts += restart.Count
+	}
+	if restarts != 1 {
+		t.Errorf("recorded %d restarts, want 1", restarts)
+	}
+}
@@ -0,0 +1,217 @@
+// Package procfs lee estadísticas de cada proceso del sistema de ficheros /proc de Linux
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Root es donde está montado el sistema de ficheros proc
+const Root = "/proc"
+
+// ClockTicks es USER_HZ, la unidad de los tiempos de CPU de /proc/<pid>/stat:
+// vale 100 en todas las arquitecturas habituales y leerlo con sysconf
+// requeriría cgo
+const ClockTicks = 100
+
+// Stat contiene los campos de /proc/<pid>/stat que usa taskmaster
+type Stat struct {
+	PID     int
+	PPID    int
+	PGRP    int
+	State   byte   // R, S, D, Z...
+	UTime   uint64 // tiempo de CPU en modo usuario, en ticks
+	STime   uint64 // tiempo de CPU en modo sistema, en ticks
+	Threads int
+}
+
+// IO contiene los contadores de E/S a almacenamiento de /proc/<pid>/io
+type IO struct {
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// Available indica si /proc se puede leer en este sistema
+func Available() bool {
+	_, err := os.Stat(filepath.Join(Root, "self", "stat"))
+	return err == nil
+}
+
+// ReadStat lee /proc/<pid>/stat
+func ReadStat(pid int) (Stat, error) {
+	data, err := os.ReadFile(procPath(pid, "stat"))
+	if err != nil {
+		return Stat{}, err
+	}
+	stat, err := parseStat(data)
+	if err != nil {
+		return Stat{}, fmt.Errorf("malformed %s: %w", procPath(pid, "stat"), err)
+	}
+	return stat, nil
+}
+
+// parseStat interpreta el contenido de un fichero /proc/<pid>/stat
+func parseStat(data []byte) (Stat, error) {
+	// El nombre del comando va entre paréntesis y puede contener espacios o
+	// paréntesis: el resto de campos empieza tras el último ')'
+	start := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return Stat{}, fmt.Errorf("no command name")
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	// fields[0] es el campo 3 (state) de proc(5)
+	if len(fields) < 18 {
+		return Stat{}, fmt.Errorf("%d fields after the command name, want at least 18", len(fields))
+	}
+
+	var stat Stat
+	var parseErr error
+	parseInt := func(s string) int {
+		n, err := strconv.Atoi(s)
+		if err != nil && parseErr == nil {
+			parseErr = err
+		}
+		return n
+	}
+	parseUint := func(s string) uint64 {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil && parseErr == nil {
+			parseErr = err
+		}
+		return n
+	}
+
+	// campo 1, antes del nombre del comando
+	stat.PID = parseInt(strings.TrimSpace(string(data[:start])))
+
+	stat.State = fields[0][0]           // campo 3
+	stat.PPID = parseInt(fields[1])     // campo 4
+	stat.PGRP = parseInt(fields[2])     // campo 5
+	stat.UTime = parseUint(fields[11])  // campo 14
+	stat.STime = parseUint(fields[12])  // campo 15
+	stat.Threads = parseInt(fields[17]) // campo 20
+	if parseErr != nil {
+		return Stat{}, parseErr
+	}
+	return stat, nil
+}
+
+// ReadRSS devuelve la memoria residente en bytes según /proc/<pid>/status
+func ReadRSS(pid int) (int64, error) {
+	file, err := os.Open(procPath(pid, "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	rss, err := parseRSS(file)
+	if err != nil {
+		return 0, fmt.Errorf("malformed VmRSS in %s: %w", procPath(pid, "status"), err)
+	}
+	return rss, nil
+}
+
+// parseRSS devuelve en bytes el VmRSS de un fichero /proc/<pid>/status
+func parseRSS(r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "VmRSS:")
+		if !found {
+			continue
+		}
+		// "VmRSS:	    1234 kB"
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb * 1024, nil
+	}
+	// Los hilos del kernel y los zombis no tienen línea VmRSS
+	return 0, scanner.Err()
+}
+
+// ReadIO lee /proc/<pid>/io, que sólo es legible para procesos del mismo
+// usuario (o con CAP_SYS_PTRACE)
+func ReadIO(pid int) (IO, error) {
+	file, err := os.Open(procPath(pid, "io"))
+	if err != nil {
+		return IO{}, err
+	}
+	defer file.Close()
+	return parseIO(file)
+}
+
+// parseIO interpreta el contenido de un fichero /proc/<pid>/io
+func parseIO(r io.Reader) (IO, error) {
+	var counters IO
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			counters.ReadBytes = n
+		case "write_bytes":
+			counters.WriteBytes = n
+		}
+	}
+	return counters, scanner.Err()
+}
+
+// CountFDs devuelve el número de descriptores abiertos de un proceso
+func CountFDs(pid int) (int, error) {
+	entries, err := os.ReadDir(procPath(pid, "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Zombie indica si el proceso terminó y sólo espera a que lo recojan
+func (s Stat) Zombie() bool {
+	return s.State == 'Z'
+}
+
+// ReadAllStats devuelve el stat de todos los procesos del sistema; se omiten
+// los que terminan mientras se recorre /proc
+func ReadAllStats() ([]Stat, error) {
+	entries, err := os.ReadDir(Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []Stat
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := ReadStat(pid)
+		if err != nil {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func procPath(pid int, name string) string {
+	return filepath.Join(Root, strconv.Itoa(pid), name)
+}
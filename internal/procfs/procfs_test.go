@@ -0,0 +1,106 @@
+package procfs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseStat(t *testing.T) {
+	// Los campos tras el nombre del comando, de state (3) a num_threads (20)
+	const rest = " S 1 4242 4242 0 -1 4194560 120 0 0 0 250 75 0 0 20 0 3 0 493416 2703360 285"
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Stat
+		wantErr bool
+	}{
+		{
+			name: "plain command",
+			data: "4243 (sleep)" + rest + "\n",
+			want: Stat{PID: 4243, PPID: 1, PGRP: 4242, State: 'S', UTime: 250, STime: 75, Threads: 3},
+		},
+		{
+			name: "command with spaces and parentheses",
+			data: "17 (my (odd) cmd)" + rest,
+			want: Stat{PID: 17, PPID: 1, PGRP: 4242, State: 'S', UTime: 250, STime: 75, Threads: 3},
+		},
+		{name: "no command name", data: "17 sleep" + rest, wantErr: true},
+		{name: "truncated", data: "17 (sleep) S 1 4242", wantErr: true},
+		{name: "non-numeric field", data: "17 (sleep) S x" + rest[4:], wantErr: true},
+		{name: "non-numeric pid", data: "pid (sleep)" + rest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStat([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseStat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    int64
+		wantErr bool
+	}{
+		{"resident", "Name:\tsleep\nVmPeak:\t    8000 kB\nVmRSS:\t    1234 kB\nThreads:\t1\n", 1234 * 1024, false},
+		{"kernel thread", "Name:\tkthreadd\nThreads:\t1\n", 0, false},
+		{"empty value", "VmRSS:\n", 0, false},
+		{"malformed", "VmRSS:\t  lots kB\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRSS(strings.NewReader(tt.status))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRSS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRSS() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIO(t *testing.T) {
+	data := "rchar: 3980\nwchar: 12\nsyscr: 9\nsyscw: 1\nread_bytes: 4096\nwrite_bytes: 8192\ncancelled_write_bytes: 0\n"
+
+	got, err := parseIO(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parseIO() error = %v", err)
+	}
+	if want := (IO{ReadBytes: 4096, WriteBytes: 8192}); got != want {
+		t.Errorf("parseIO() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadSelf(t *testing.T) {
+	if !Available() {
+		t.Skip("/proc is not available")
+	}
+	pid := os.Getpid()
+
+	stat, err := ReadStat(pid)
+	if err != nil {
+		t.Fatalf("ReadStat(self) error = %v", err)
+	}
+	if stat.PID != pid || stat.PPID != os.Getppid() || stat.Threads < 1 {
+		t.Errorf("ReadStat(self) = %+v, want pid %d and ppid %d", stat, pid, os.Getppid())
+	}
+
+	if rss, err := ReadRSS(pid); err != nil || rss <= 0 {
+		t.Errorf("ReadRSS(self) = %d, %v, want a positive size", rss, err)
+	}
+	if fds, err := CountFDs(pid); err != nil || fds < 3 {
+		t.Errorf("CountFDs(self) = %d, %v, want at least 3", fds, err)
+	}
+}
@@ -2,8 +2,10 @@ package shell
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"syscall"
+	"taskmaster/internal/config"
 	"taskmaster/internal/logger"
 	"taskmaster/internal/process"
 	"time"
@@ -57,6 +59,11 @@ func (s *Shell) Run() {
 	}
 }
 
+// Close interrumpe la lectura del shell y restaura el terminal
+func (s *Shell) Close() {
+	s.rl.Close()
+}
+
 func (s *Shell) executeCommand(line string) bool {
 	parts := strings.Fields(line)
 	if len(parts) == 0 {
@@ -71,26 +78,48 @@ func (s *Shell) executeCommand(line string) bool {
 		s.showHelp()
 	case "status":
 		s.showStatus()
+	case "top":
+		s.showTop()
 	case "start":
 		if len(args) == 0 {
-			fmt.Println("Usage: start <program_name>")
+			fmt.Println("Usage: start <program_name|program:N>")
 			return false
 		}
 		s.startProgram(args[0])
 	case "stop":
 		if len(args) == 0 {
-			fmt.Println("Usage: stop <program_name>")
+			fmt.Println("Usage: stop <program_name|program:N>")
 			return false
 		}
 		s.stopProgram(args[0])
 	case "restart":
 		if len(args) == 0 {
-			fmt.Println("Usage: restart <program_name>")
+			fmt.Println("Usage: restart <program_name|program:N>")
 			return false
 		}
 		s.restartProgram(args[0])
+	case "signal":
+		if len(args) < 2 {
+			fmt.Println("Usage: signal <SIGNAL> <program_name|program:N>")
+			return false
+		}
+		s.signalTarget(args[0], args[1])
+	case "scale":
+		if len(args) < 2 {
+			fmt.Println("Usage: scale <program_name> <numprocs>")
+			return false
+		}
+		s.scaleProgram(args[0], args[1])
 	case "reload":
-		s.reloadConfig()
+		if len(args) > 0 && args[0] == "--dry-run" {
+			s.previewReload()
+		} else if len(args) > 0 {
+			fmt.Println("Usage: reload [--dry-run]")
+		} else {
+			s.reloadConfig()
+		}
+	case "tail":
+		s.tailOutput(args)
 	case "clear":
 		if len(args) == 0 {
 			s.clearDeadProcesses()
@@ -110,10 +139,14 @@ func (s *Shell) showHelp() {
 	fmt.Println("📚 Available commands:")
 	fmt.Println("  help     - Show this help message")
 	fmt.Println("  status   - Show status of all programs")
-	fmt.Println("  start    - Start a program")
-	fmt.Println("  stop     - Stop a program")
-	fmt.Println("  restart  - Restart a program")
-	fmt.Println("  reload   - Reload configuration file")
+	fmt.Println("  top      - Show CPU, memory, threads, FDs and I/O of running instances")
+	fmt.Println("  start    - Start a program or one instance (prog:N / prog_N)")
+	fmt.Println("  stop     - Stop a program or one instance")
+	fmt.Println("  restart  - Restart a program or one instance")
+	fmt.Println("  signal   - Send a signal to a program or one instance (signal HUP prog:1)")
+	fmt.Println("  scale    - Change the number of instances of a program (scale prog 4)")
+	fmt.Println("  reload   - Reload configuration file (reload --dry-run only shows the plan)")
+	fmt.Println("  tail     - Show program output (tail prog [stdout|stderr] [-n N] [-f])")
 	fmt.Println("  clear [program] - Clean process history (optional)")
 	fmt.Println("  quit/exit - Exit taskmaster")
 }
@@ -153,7 +186,8 @@ func (s *Shell) showStatus() {
 			}
 
 			// Para procesos terminados, no mostrar PID
-			if instance.State == process.StateStopped || instance.State == process.StateFailed {
+			if instance.State == process.StateStopped || instance.State == process.StateFailed ||
+				instance.State == process.StateFatal || instance.State == process.StateBackoff {
 				pidStr = "-"
 			}
 
@@ -170,14 +204,50 @@ func (s *Shell) showStatus() {
 	}
 }
 
+// showTop muestra la última muestra de /proc de cada instancia
+func (s *Shell) showTop() {
+	status := s.manager.Snapshot()
+	if len(status) == 0 {
+		fmt.Println("📋 No programs configured")
+		return
+	}
+
+	fmt.Printf("%-20s %-12s %-8s %-6s %-7s %-9s %-8s %-6s %-9s %-9s\n",
+		"NAME", "STATE", "PID", "PROCS", "CPU%", "RSS", "THREADS", "FDS", "READ", "WRITE")
+	fmt.Println(strings.Repeat("-", 102))
+
+	for _, info := range status {
+		stateColor := s.getStateColor(info.State)
+		if info.Usage == nil {
+			fmt.Printf("%-20s %s%-12s\033[0m %-8s %-6s %-7s %-9s %-8s %-6s %-9s %-9s\n",
+				info.Name, stateColor, info.State.String(), "-", "-", "-", "-", "-", "-", "-", "-")
+			continue
+		}
+
+		usage := info.Usage
+		fmt.Printf("%-20s %s%-12s\033[0m %-8d %-6d %-7.1f %-9s %-8d %-6d %-9s %-9s\n",
+			info.Name,
+			stateColor,
+			info.State.String(),
+			info.PID,
+			usage.Processes,
+			usage.CPUPercent,
+			config.ByteSize(usage.RSS),
+			usage.Threads,
+			usage.OpenFDs,
+			config.ByteSize(usage.ReadBytes),
+			config.ByteSize(usage.WriteBytes))
+	}
+}
+
 func (s *Shell) getStateColor(state process.ProcessState) string {
 	stateStr := state.String()
 	switch stateStr {
 	case "RUNNING":
 		return "\033[32m" // Verde
-	case "FAILED":
+	case "FAILED", "FATAL":
 		return "\033[31m" // Rojo
-	case "STARTING", "RESTARTING":
+	case "STARTING", "RESTARTING", "BACKOFF":
 		return "\033[33m" // Amarillo
 	case "STOPPED":
 		return "\033[90m" // Gris
@@ -188,16 +258,16 @@ func (s *Shell) getStateColor(state process.ProcessState) string {
 
 func (s *Shell) startProgram(name string) {
 	fmt.Printf("🚀 Starting program %s...\n", name)
-	if err := s.manager.StartProgram(name); err != nil {
+	if err := s.manager.StartTarget(name); err != nil {
 		fmt.Printf("❌ Error starting program %s: %v\n", name, err)
 	} else {
-		fmt.Printf("✅ Program %s started successfully\n", name)
+		fmt.Printf("✅ Program %s started, STARTING until starttime elapses (check status)\n", name)
 	}
 }
 
 func (s *Shell) stopProgram(name string) {
 	fmt.Printf("🛑 Stopping program %s...\n", name)
-	if err := s.manager.StopProgram(name); err != nil {
+	if err := s.manager.StopTarget(name); err != nil {
 		fmt.Printf("❌ Error stopping program %s: %v\n", name, err)
 	} else {
 		fmt.Printf("✅ Program %s stopped successfully\n", name)
@@ -206,21 +276,35 @@ func (s *Shell) stopProgram(name string) {
 
 func (s *Shell) restartProgram(name string) {
 	fmt.Printf("🔄 Restarting program %s...\n", name)
+	if err := s.manager.RestartTarget(name); err != nil {
+		fmt.Printf("❌ Error restarting program %s: %v\n", name, err)
+	} else {
+		fmt.Printf("✅ Program %s restarted successfully\n", name)
+	}
+}
 
-	// Parar el programa
-	if err := s.manager.StopProgram(name); err != nil {
-		fmt.Printf("❌ Error stopping program %s: %v\n", name, err)
-		return
+func (s *Shell) signalTarget(signalName, target string) {
+	signalName = strings.TrimPrefix(strings.ToUpper(signalName), "SIG")
+	fmt.Printf("📡 Sending %s to %s...\n", signalName, target)
+	if err := s.manager.SignalTarget(target, signalName); err != nil {
+		fmt.Printf("❌ Error signaling %s: %v\n", target, err)
+	} else {
+		fmt.Printf("✅ Signal %s sent to %s\n", signalName, target)
 	}
+}
 
-	// Pequeña pausa para asegurar que el proceso se detenga
-	time.Sleep(2 * time.Second)
+func (s *Shell) scaleProgram(name, count string) {
+	numProcs, err := strconv.Atoi(count)
+	if err != nil {
+		fmt.Printf("❌ Invalid number of processes: %s\n", count)
+		return
+	}
 
-	// Iniciar el programa
-	if err := s.manager.StartProgram(name); err != nil {
-		fmt.Printf("❌ Error starting program %s: %v\n", name, err)
+	fmt.Printf("📐 Scaling program %s to %d instance(s)...\n", name, numProcs)
+	if err := s.manager.ScaleProgram(name, numProcs); err != nil {
+		fmt.Printf("❌ Error scaling program %s: %v\n", name, err)
 	} else {
-		fmt.Printf("✅ Program %s restarted successfully\n", name)
+		fmt.Printf("✅ Program %s scaled to %d instance(s)\n", name, numProcs)
 	}
 }
 
@@ -246,10 +330,104 @@ func (s *Shell) reloadConfig() {
 		return
 	}
 
-	if err := s.manager.ReloadConfig(s.configFile); err != nil {
+	report, err := s.manager.ReloadConfig(s.configFile)
+	if err != nil {
 		fmt.Printf("❌ Error reloading configuration: %v\n", err)
+		return
+	}
+
+	for _, line := range report.Lines() {
+		fmt.Printf("  %s\n", line)
+	}
+	if report.RolledBack {
+		fmt.Println("⚠️  Reload failed, previous configuration restored")
+	} else if report.Failed() {
+		fmt.Println("⚠️  Configuration reloaded with errors")
 	} else {
 		fmt.Println("✅ Configuration reloaded successfully")
-		fmt.Println("ℹ️  Check status to see configuration changes")
 	}
 }
+
+func (s *Shell) previewReload() {
+	fmt.Println("🔍 Computing reload plan...")
+
+	if s.configFile == "" {
+		fmt.Println("❌ No configuration file specified")
+		return
+	}
+
+	report, err := s.manager.PlanReload(s.configFile)
+	if err != nil {
+		fmt.Printf("❌ Error loading configuration: %v\n", err)
+		return
+	}
+
+	for _, line := range report.Lines() {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+func (s *Shell) tailOutput(args []string) {
+	req, err := process.ParseTailArgs(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("Usage: tail <program_name|program:N> [stdout|stderr] [-n N] [-f]")
+		return
+	}
+
+	// Suscribirse antes de leer el búfer para no perder líneas entre ambos pasos
+	var follow <-chan process.OutputLine
+	var stop func()
+	if req.Follow {
+		if follow, stop, err = s.manager.FollowOutput(req.Target, req.Stream); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		defer stop()
+	}
+
+	lines, err := s.manager.Tail(req.Target, req.Stream, req.Lines)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	cursor := make(process.OutputCursor)
+	for _, line := range lines {
+		fmt.Println(formatOutputLine(line))
+		cursor.Advance(line)
+	}
+
+	if !req.Follow {
+		return
+	}
+
+	fmt.Println("👀 Following output, press Enter or Ctrl-C to stop")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for line := range follow {
+			if !cursor.Advance(line) {
+				continue
+			}
+			fmt.Fprintln(s.rl.Stdout(), formatOutputLine(line))
+		}
+	}()
+
+	// Readline devuelve con Enter o Ctrl-C; el terminal está en modo raw, así
+	// que Ctrl-C no llega como SIGINT al supervisor
+	s.rl.SetPrompt("")
+	s.rl.Readline()
+	s.rl.SetPrompt("taskmaster> ")
+
+	stop()
+	<-done
+}
+
+// formatOutputLine muestra una línea de salida precedida de su instancia
+func formatOutputLine(line process.OutputLine) string {
+	color := "\033[90m"
+	if line.Stream == process.StreamStderr {
+		color = "\033[31m"
+	}
+	return fmt.Sprintf("%s[%s]\033[0m %s", color, line.Instance, line.Text)
+}
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmaster/internal/process"
+	"taskmaster/pkg/signals"
+)
+
+// Error codes returned in the "code" field of API error bodies.
+const (
+	codeBadRequest       = "bad_request"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeInvalidConfig    = "invalid_config"
+	codeRolledBack       = "reload_rolled_back"
+	codeUnavailable      = "unavailable"
+)
+
+// maxRequestBody bounds the size of request bodies read by the API.
+const maxRequestBody = 4096
+
+// apiError is the body of every failed API request.
+type apiError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// signalRequest is the body of POST /api/programs/{name}/signal.
+type signalRequest struct {
+	Signal string `json:"signal"`
+}
+
+// reloadResponse is the body of POST /api/reload, on success and on rollback.
+type reloadResponse struct {
+	*process.ReloadReport
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// registerAPI adds the REST control API to mux.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/api/programs", allow("GET", s.handleListPrograms))
+	mux.HandleFunc("/api/programs/{name}", allow("GET", s.handleGetProgram))
+	mux.HandleFunc("/api/programs/{name}/{action}", allow("POST", s.handleProgramAction))
+	mux.HandleFunc("/api/reload", allow("POST", s.handleReload))
+	mux.HandleFunc("/api/clear", allow("POST", s.handleClear))
+
+	// Anything else under /api/ gets a JSON error instead of the HTML 404 page
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, codeNotFound, "no such endpoint: "+r.Method+" "+r.URL.Path)
+	})
+}
+
+// allow rejects requests with another method with a JSON 405 error.
+func allow(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method "+r.Method+" not allowed, use "+method)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleListPrograms(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.manager.Programs())
+}
+
+func (s *Server) handleGetProgram(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	program, exists := s.manager.Program(name)
+	if !exists {
+		writeError(w, http.StatusNotFound, codeNotFound, "program "+name+" not found in configuration")
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+// apiFailure is an error together with the HTTP status and code it is
+// reported with, over REST and over the WebSocket.
+type apiFailure struct {
+	status  int
+	code    string
+	message string
+}
+
+func fail(status int, code, message string) *apiFailure {
+	return &apiFailure{status: status, code: code, message: message}
+}
+
+// handleProgramAction runs start, stop, restart or signal on a program or on
+// one of its instances (web:1 or web_1), then returns the program.
+func (s *Server) handleProgramAction(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("action")
+
+	signalName := ""
+	if action == "signal" {
+		var req signalRequest
+		body := io.LimitReader(r.Body, maxRequestBody)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			message := "invalid request body: " + err.Error()
+			if errors.Is(err, io.EOF) {
+				message = `request body must be {"signal": "NAME"}`
+			}
+			writeError(w, http.StatusBadRequest, codeBadRequest, message)
+			return
+		}
+		signalName = req.Signal
+	}
+
+	program, failure := s.programAction(r.PathValue("name"), action, signalName)
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+// programAction runs an action on a target and returns the target's program.
+func (s *Server) programAction(target, action, signalName string) (process.ProgramInfo, *apiFailure) {
+	program, err := s.manager.TargetProgram(target)
+	if err != nil {
+		return process.ProgramInfo{}, fail(http.StatusNotFound, codeNotFound, err.Error())
+	}
+
+	switch action {
+	case "start":
+		err = s.manager.StartTarget(target)
+	case "stop":
+		err = s.manager.StopTarget(target)
+	case "restart":
+		err = s.manager.RestartTarget(target)
+	case "signal":
+		name, failure := parseSignal(signalName)
+		if failure != nil {
+			return process.ProgramInfo{}, failure
+		}
+		err = s.manager.SignalTarget(target, name)
+	default:
+		return process.ProgramInfo{}, fail(http.StatusNotFound, codeNotFound, "unknown action: "+action)
+	}
+
+	if err != nil {
+		return process.ProgramInfo{}, fail(http.StatusConflict, codeConflict, err.Error())
+	}
+	info, _ := s.manager.Program(program)
+	return info, nil
+}
+
+// parseSignal validates a signal name such as HUP, sighup or SIGHUP.
+func parseSignal(signalName string) (string, *apiFailure) {
+	name := strings.TrimPrefix(strings.ToUpper(signalName), "SIG")
+	if name == "" {
+		return "", fail(http.StatusBadRequest, codeBadRequest, "signal is required")
+	}
+	if !signals.IsValidSignal(name) {
+		return "", fail(http.StatusBadRequest, codeBadRequest, "unknown signal: "+signalName)
+	}
+	return name, nil
+}
+
+// handleReload reloads the configuration file, or only plans the reload with
+// ?dry_run=true.
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "invalid dry_run value: "+value)
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, failure := s.reloadConfig(dryRun)
+	if failure != nil && report == nil {
+		writeFailure(w, failure)
+		return
+	}
+	if failure != nil {
+		writeJSON(w, failure.status, reloadResponse{ReloadReport: report, Error: failure.message, Code: failure.code})
+		return
+	}
+	writeJSON(w, http.StatusOK, reloadResponse{ReloadReport: report})
+}
+
+// reloadConfig reloads or plans a reload. A rolled back reload returns both
+// the report and a failure.
+func (s *Server) reloadConfig(dryRun bool) (*process.ReloadReport, *apiFailure) {
+	if s.configFile == "" {
+		return nil, fail(http.StatusServiceUnavailable, codeUnavailable, "no configuration file specified")
+	}
+
+	reload := s.manager.ReloadConfig
+	if dryRun {
+		reload = s.manager.PlanReload
+	}
+	report, err := reload(s.configFile)
+	if err != nil {
+		return nil, fail(http.StatusUnprocessableEntity, codeInvalidConfig, err.Error())
+	}
+	if report.RolledBack {
+		return report, fail(http.StatusConflict, codeRolledBack, "reload rolled back: "+report.RollbackReason)
+	}
+	return report, nil
+}
+
+// handleClear removes stopped instances, of every program or of ?program=name.
+func (s *Server) handleClear(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("program")
+	if name == "" {
+		s.manager.CleanupDeadProcesses()
+		writeJSON(w, http.StatusOK, s.manager.Programs())
+		return
+	}
+
+	if !s.manager.IsProgram(name) {
+		writeError(w, http.StatusNotFound, codeNotFound, "program "+name+" not found in configuration")
+		return
+	}
+	s.manager.CleanupProgram(name)
+	program, _ := s.manager.Program(name)
+	writeJSON(w, http.StatusOK, program)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiError{Error: message, Code: code})
+}
+
+func writeFailure(w http.ResponseWriter, failure *apiFailure) {
+	writeError(w, failure.status, failure.code, failure.message)
+}
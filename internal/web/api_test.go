@@ -0,0 +1,159 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// request sends a request with an optional JSON body and decodes the response
+// into out.
+func request(t *testing.T, method, url, body string, out interface{}) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("%s %s Content-Type = %q, want application/json", method, url, got)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("%s %s: decode body: %v", method, url, err)
+	}
+	return resp
+}
+
+func TestAPIErrors(t *testing.T) {
+	_, srv := newTestServer(t, testConfig, Options{})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		code    string
+		message string
+	}{
+		{"unknown program", "GET", "/api/programs/ghost", "", http.StatusNotFound, codeNotFound, "program ghost not found in configuration"},
+		{"unknown endpoint", "GET", "/api/nope", "", http.StatusNotFound, codeNotFound, "no such endpoint: GET /api/nope"},
+		{"unknown action", "POST", "/api/programs/web/explode", "", http.StatusNotFound, codeNotFound, "unknown action: explode"},
+		{"unknown instance", "POST", "/api/programs/web:7/start", "", http.StatusNotFound, codeNotFound, ""},
+		{"wrong method", "GET", "/api/reload", "", http.StatusMethodNotAllowed, codeMethodNotAllowed, "method GET not allowed, use POST"},
+		{"wrong method on a program", "DELETE", "/api/programs/web", "", http.StatusMethodNotAllowed, codeMethodNotAllowed, "method DELETE not allowed, use GET"},
+		{"unknown signal", "POST", "/api/programs/web/signal", `{"signal": "FOO"}`, http.StatusBadRequest, codeBadRequest, "unknown signal: FOO"},
+		{"missing signal", "POST", "/api/programs/web/signal", `{}`, http.StatusBadRequest, codeBadRequest, "signal is required"},
+		{"missing body", "POST", "/api/programs/web/signal", "", http.StatusBadRequest, codeBadRequest, `request body must be {"signal": "NAME"}`},
+		{"invalid dry_run", "POST", "/api/reload?dry_run=maybe", "", http.StatusBadRequest, codeBadRequest, "invalid dry_run value: maybe"},
+		{"stopping a stopped program", "POST", "/api/programs/web/stop", "", http.StatusConflict, codeConflict, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body apiError
+			resp := request(t, tt.method, srv.URL+tt.path, tt.body, &body)
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if body.Code != tt.code {
+				t.Errorf("code = %q, want %q", body.Code, tt.code)
+			}
+			if body.Error == "" || (tt.message != "" && body.Error != tt.message) {
+				t.Errorf("error = %q, want %q", body.Error, tt.message)
+			}
+			if tt.status == http.StatusMethodNotAllowed && resp.Header.Get("Allow") == "" {
+				t.Errorf("405 response without an Allow header")
+			}
+		})
+	}
+}
+
+func TestAPIStatusOrder(t *testing.T) {
+	_, srv := newTestServer(t, `programs:
+  web:
+    cmd: "sleep 100"
+    numprocs: 12
+    autostart: false
+  db:
+    cmd: "sleep 100"
+    numprocs: 2
+    autostart: false
+`, Options{})
+
+	for _, name := range []string{"web", "db"} {
+		var program struct{}
+		if resp := request(t, "POST", srv.URL+"/api/programs/"+name+"/start", "", &program); resp.StatusCode != http.StatusOK {
+			t.Fatalf("start %s: status %d", name, resp.StatusCode)
+		}
+	}
+
+	// Programs by name, instances by index: web_2 comes before web_10
+	want := []string{"db_0", "db_1"}
+	for i := 0; i < 12; i++ {
+		want = append(want, fmt.Sprintf("web_%d", i))
+	}
+
+	var status []struct {
+		Name string `json:"name"`
+	}
+	request(t, "GET", srv.URL+"/api/status", "", &status)
+	var got []string
+	for _, instance := range status {
+		got = append(got, instance.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/api/status order = %v, want %v", got, want)
+	}
+
+	var programs []struct {
+		Name      string `json:"name"`
+		Instances []struct {
+			Name string `json:"name"`
+		} `json:"instances"`
+	}
+	request(t, "GET", srv.URL+"/api/programs", "", &programs)
+	got = got[:0]
+	for _, program := range programs {
+		for _, instance := range program.Instances {
+			got = append(got, instance.Name)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/api/programs order = %v, want %v", got, want)
+	}
+}
+
+func TestAPIReloadRollback(t *testing.T) {
+	s, srv := newTestServer(t, testConfig, Options{})
+
+	content := testConfig + "  crash:\n    cmd: \"false\"\n    autostart: true\n    starttime: 1\n    startretries: 0\n"
+	if err := os.WriteFile(s.configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		RolledBack bool   `json:"rolled_back"`
+		Error      string `json:"error"`
+		Code       string `json:"code"`
+	}
+	resp := request(t, "POST", srv.URL+"/api/reload", "", &body)
+	if resp.StatusCode != http.StatusConflict || body.Code != codeRolledBack || !body.RolledBack {
+		t.Fatalf("status = %d, code = %q, rolled_back = %v, want 409, %q, true", resp.StatusCode, body.Code, body.RolledBack, codeRolledBack)
+	}
+	if want := "reload rolled back: instance crash_0 of program crash is FATAL"; !strings.HasPrefix(body.Error, want) {
+		t.Errorf("error = %q, want it to start with %q", body.Error, want)
+	}
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Options controls where the web server listens and who may use it. The zero
+// value serves plain HTTP on localhost without authentication.
+type Options struct {
+	BindAddress    string // interface to listen on ("" = 127.0.0.1, "0.0.0.0" = all)
+	TLSCertFile    string // serve HTTPS when both files are set
+	TLSKeyFile     string
+	Username       string // HTTP basic auth, together with Password
+	Password       string
+	Token          string   // accepted as "Authorization: Bearer <token>"
+	AllowedOrigins []string // origins allowed besides the server's own host
+}
+
+// DefaultBindAddress is the interface the web server listens on by default.
+const DefaultBindAddress = "127.0.0.1"
+
+// authEnabled reports whether requests must carry credentials.
+func (o Options) authEnabled() bool {
+	return o.Username != "" || o.Token != ""
+}
+
+// tlsEnabled reports whether the server speaks HTTPS.
+func (o Options) tlsEnabled() bool {
+	return o.TLSCertFile != "" && o.TLSKeyFile != ""
+}
+
+// secure wraps every endpoint, including /ws and the static files, with the
+// authentication and origin checks.
+func (s *Server) secure(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			s.logger.Warn("Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			if s.options.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="taskmaster", charset="UTF-8"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="taskmaster"`)
+			}
+			writeError(w, http.StatusUnauthorized, codeUnauthorized, "authentication required")
+			return
+		}
+
+		// Browsers send credentials with cross-site requests, so anything that
+		// changes state or opens the WebSocket must come from an allowed page
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.URL.Path == "/ws" {
+			if !s.originAllowed(r) {
+				s.logger.Warn("Rejected %s %s from origin %s", r.Method, r.URL.Path, r.Header.Get("Origin"))
+				writeError(w, http.StatusForbidden, codeForbidden, "origin not allowed: "+r.Header.Get("Origin"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized checks the bearer token or the basic auth credentials in
+// constant time.
+func (s *Server) authorized(r *http.Request) bool {
+	if !s.options.authEnabled() {
+		return true
+	}
+
+	if s.options.Token != "" {
+		header := r.Header.Get("Authorization")
+		if token, found := strings.CutPrefix(header, "Bearer "); found && secureEqual(token, s.options.Token) {
+			return true
+		}
+	}
+
+	if s.options.Username != "" {
+		username, password, ok := r.BasicAuth()
+		if ok && secureEqual(username, s.options.Username) && secureEqual(password, s.options.Password) {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed accepts requests without an Origin header (non-browser
+// clients), from the server's own host and from the configured origins.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range s.options.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, r.Host)
+}
+
+// isLoopback reports whether address only accepts local connections.
+func isLoopback(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
+
+func secureEqual(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"taskmaster/internal/config"
+	"taskmaster/internal/logfile"
+	"taskmaster/internal/logger"
+	"taskmaster/internal/process"
+)
+
+// testConfig is a program that never starts on its own.
+const testConfig = "programs:\n  web:\n    cmd: \"sleep 100\"\n    autostart: false\n"
+
+// newTestServer serves the web endpoints for the given configuration over
+// httptest, with a manager that logs to a temporary file.
+func newTestServer(t *testing.T, content string, options Options) (*Server, *httptest.Server) {
+	t.Helper()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "taskmaster.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+
+	log, err := logger.New(filepath.Join(dir, "taskmaster.log"), logfile.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.SetConsole(false)
+	t.Cleanup(func() { log.Close() })
+
+	manager := process.NewManager(cfg, log)
+	t.Cleanup(func() { manager.Shutdown(5 * time.Second) })
+
+	s := NewServer(0, manager, log)
+	s.SetOptions(options)
+	s.SetConfigFile(path)
+	go s.hub.Run()
+
+	srv := httptest.NewServer(s.handler())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// do sends a request with the given headers and returns the response and the
+// decoded error code, if the body is an API error.
+func do(t *testing.T, method, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	var body apiError
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp, body.Code
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func basic(username, password string) http.Header {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.SetBasicAuth(username, password)
+	return req.Header
+}
+
+func TestSecureAuthentication(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   Options
+		header    http.Header
+		status    int
+		challenge string
+	}{
+		{"no auth configured", Options{}, nil, http.StatusOK, ""},
+		{"missing token", Options{Token: "s3cret"}, nil, http.StatusUnauthorized, "Bearer"},
+		{"wrong token", Options{Token: "s3cret"}, bearer("guess"), http.StatusUnauthorized, "Bearer"},
+		{"bearer token", Options{Token: "s3cret"}, bearer("s3cret"), http.StatusOK, ""},
+		{"missing basic auth", Options{Username: "admin", Password: "pw"}, nil, http.StatusUnauthorized, "Basic"},
+		{"wrong password", Options{Username: "admin", Password: "pw"}, basic("admin", "nope"), http.StatusUnauthorized, "Basic"},
+		{"basic auth", Options{Username: "admin", Password: "pw"}, basic("admin", "pw"), http.StatusOK, ""},
+		{"basic auth with a token configured", Options{Username: "admin", Password: "pw", Token: "s3cret"}, basic("admin", "pw"), http.StatusOK, ""},
+		{"token with basic auth configured", Options{Username: "admin", Password: "pw", Token: "s3cret"}, bearer("s3cret"), http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newTestServer(t, testConfig, tt.options)
+
+			resp, code := do(t, "GET", srv.URL+"/api/programs", tt.header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusUnauthorized {
+				return
+			}
+			if code != codeUnauthorized {
+				t.Errorf("error code = %q, want %q", code, codeUnauthorized)
+			}
+			if got := resp.Header.Get("WWW-Authenticate"); !strings.HasPrefix(got, tt.challenge+" ") {
+				t.Errorf("WWW-Authenticate = %q, want a %s challenge", got, tt.challenge)
+			}
+		})
+	}
+}
+
+func TestSecureOrigin(t *testing.T) {
+	options := Options{Token: "s3cret", AllowedOrigins: []string{"https://dash.example/"}}
+	_, srv := newTestServer(t, testConfig, options)
+	sameHost := "http://" + srv.Listener.Addr().String()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		origin string
+		status int
+	}{
+		{"no origin", "POST", "/api/clear", "", http.StatusOK},
+		{"same host", "POST", "/api/clear", sameHost, http.StatusOK},
+		{"allowed origin", "POST", "/api/clear", "https://dash.example", http.StatusOK},
+		{"foreign origin", "POST", "/api/clear", "https://evil.example", http.StatusForbidden},
+		{"foreign origin reading", "GET", "/api/programs", "https://evil.example", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := bearer("s3cret")
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			resp, code := do(t, tt.method, srv.URL+tt.path, header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusForbidden && code != codeForbidden {
+				t.Errorf("error code = %q, want %q", code, codeForbidden)
+			}
+		})
+	}
+}
+
+func TestSecureWebSocket(t *testing.T) {
+	_, srv := newTestServer(t, testConfig, Options{Token: "s3cret"})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	sameHost := "http://" + srv.Listener.Addr().String()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		origin string
+		status int
+	}{
+		{"missing token", nil, sameHost, http.StatusUnauthorized},
+		{"foreign origin", bearer("s3cret"), "https://evil.example", http.StatusForbidden},
+		{"same host", bearer("s3cret"), sameHost, http.StatusSwitchingProtocols},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Origin": {tt.origin}}
+			for key, values := range tt.header {
+				header[key] = values
+			}
+
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if conn != nil {
+				conn.Close()
+			}
+			if resp == nil {
+				t.Fatalf("Dial() error: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("handshake status = %d, want %d (error: %v)", resp.StatusCode, tt.status, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"taskmaster/internal/process"
+)
+
+// CommandMessage is a command sent by a dashboard over the WebSocket:
+//
+//	{"type": "command", "id": "7", "command": "restart", "target": "web:1"}
+//
+// Commands are status, start, stop, restart, signal (with "signal") and
+// reload (with an optional "dry_run").
+type CommandMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	Target  string `json:"target,omitempty"`
+	Signal  string `json:"signal,omitempty"`
+	DryRun  bool   `json:"dry_run,omitempty"`
+}
+
+// CommandResult answers a CommandMessage with the same ID. It is sent as a
+// "result" message only to the client that sent the command.
+type CommandResult struct {
+	ID       string                `json:"id"`
+	Command  string                `json:"command"`
+	Target   string                `json:"target,omitempty"`
+	OK       bool                  `json:"ok"`
+	Error    string                `json:"error,omitempty"`
+	Code     string                `json:"code,omitempty"`
+	Program  *process.ProgramInfo  `json:"program,omitempty"`
+	Programs []process.ProgramInfo `json:"programs,omitempty"`
+	Reload   *process.ReloadReport `json:"reload,omitempty"`
+}
+
+// maxCommandSize bounds the size of a message read from a client.
+const maxCommandSize = 4096
+
+// reply is a result waiting to be queued on a client's send channel.
+type reply struct {
+	client *Client
+	data   []byte
+}
+
+// handleCommand decodes a client message and runs it in the background, so
+// that slow commands (a stop waits up to stoptime) don't block the read loop.
+func (c *Client) handleCommand(data []byte) {
+	var msg CommandMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.hub.sendResult(c, CommandResult{OK: false, Error: "invalid message: " + err.Error(), Code: codeBadRequest})
+		return
+	}
+	if msg.Type != "command" {
+		c.hub.sendResult(c, CommandResult{ID: msg.ID, OK: false, Error: "unknown message type: " + msg.Type, Code: codeBadRequest})
+		return
+	}
+	if c.hub.execute == nil {
+		c.hub.sendResult(c, CommandResult{ID: msg.ID, Command: msg.Command, OK: false, Error: "commands are not available", Code: codeUnavailable})
+		return
+	}
+
+	go func() {
+		c.hub.sendResult(c, c.hub.execute(msg))
+	}()
+}
+
+// sendResult queues a result for one client through the hub, which drops it
+// if the client has disconnected in the meantime.
+func (h *Hub) sendResult(client *Client, result CommandResult) {
+	data, err := json.Marshal(Message{Type: "result", Timestamp: time.Now(), Data: result})
+	if err != nil {
+		h.logger.Error("Failed to marshal command result: %v", err)
+		return
+	}
+	h.replies <- reply{client: client, data: data}
+}
+
+// executeCommand runs a dashboard command with the same rules as the REST API.
+func (s *Server) executeCommand(msg CommandMessage) CommandResult {
+	result := CommandResult{ID: msg.ID, Command: msg.Command, Target: msg.Target, OK: true}
+
+	var failure *apiFailure
+	switch msg.Command {
+	case "status":
+		result.Programs = s.manager.Programs()
+	case "start", "stop", "restart", "signal":
+		if msg.Target == "" {
+			failure = fail(http.StatusBadRequest, codeBadRequest, msg.Command+" requires a target")
+			break
+		}
+		s.logger.Info("🌐 Web command: %s %s", msg.Command, msg.Target)
+		var program process.ProgramInfo
+		program, failure = s.programAction(msg.Target, msg.Command, msg.Signal)
+		if failure == nil {
+			result.Program = &program
+		}
+	case "reload":
+		s.logger.Info("🌐 Web command: reload")
+		result.Reload, failure = s.reloadConfig(msg.DryRun)
+	default:
+		failure = fail(http.StatusBadRequest, codeBadRequest, "unknown command: "+msg.Command)
+	}
+
+	if failure != nil {
+		result.OK = false
+		result.Error = failure.message
+		result.Code = failure.code
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWS opens a WebSocket to the test server as a same-host page.
+func dialWS(t *testing.T, srvURL string) *websocket.Conn {
+	t.Helper()
+	origin := http.Header{"Origin": {srvURL}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srvURL, "http")+"/ws", origin)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readResult skips broadcasts until the result of the command with id.
+func readResult(t *testing.T, conn *websocket.Conn, id string) CommandResult {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for result %s: %v", id, err)
+		}
+		if msg.Type != "result" {
+			continue
+		}
+		var result CommandResult
+		if err := json.Unmarshal(msg.Data, &result); err != nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		if result.ID == id {
+			return result
+		}
+	}
+}
+
+func TestWebSocketCommands(t *testing.T) {
+	_, srv := newTestServer(t, testConfig, Options{})
+	conn := dialWS(t, srv.URL)
+
+	tests := []struct {
+		name    string
+		command CommandMessage
+		ok      bool
+		code    string
+		error   string
+	}{
+		{"status", CommandMessage{Command: "status"}, true, "", ""},
+		{"start", CommandMessage{Command: "start", Target: "web:0"}, true, "", ""},
+		{"missing target", CommandMessage{Command: "stop"}, false, codeBadRequest, "stop requires a target"},
+		{"unknown program", CommandMessage{Command: "start", Target: "ghost"}, false, codeNotFound, ""},
+		{"unknown signal", CommandMessage{Command: "signal", Target: "web", Signal: "FOO"}, false, codeBadRequest, "unknown signal: FOO"},
+		{"unknown command", CommandMessage{Command: "explode"}, false, codeBadRequest, "unknown command: explode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.command.Type = "command"
+			tt.command.ID = tt.name
+			if err := conn.WriteJSON(tt.command); err != nil {
+				t.Fatalf("WriteJSON() error: %v", err)
+			}
+
+			result := readResult(t, conn, tt.command.ID)
+			if result.OK != tt.ok || result.Code != tt.code {
+				t.Fatalf("result ok = %v, code = %q, want %v, %q (error: %s)", result.OK, result.Code, tt.ok, tt.code, result.Error)
+			}
+			if tt.error != "" && result.Error != tt.error {
+				t.Errorf("error = %q, want %q", result.Error, tt.error)
+			}
+			if result.Command != tt.command.Command {
+				t.Errorf("command = %q, want %q", result.Command, tt.command.Command)
+			}
+		})
+	}
+}
+
+func TestWebSocketInvalidMessages(t *testing.T) {
+	_, srv := newTestServer(t, testConfig, Options{})
+	conn := dialWS(t, srv.URL)
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "id": "x"}`))
+	if result := readResult(t, conn, "x"); result.OK || result.Error != "unknown message type: subscribe" {
+		t.Errorf("result = %+v, want an unknown message type error", result)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`not json`))
+	result := readResult(t, conn, "")
+	if result.OK || result.Code != codeBadRequest || !strings.HasPrefix(result.Error, "invalid message: ") {
+		t.Errorf("result = %+v, want an invalid message error", result)
+	}
+}
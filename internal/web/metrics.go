@@ -0,0 +1,115 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmaster/internal/process"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// handleMetrics serves the supervised programs and the supervisor itself in
+// the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := s.manager.Metrics()
+	now := time.Now()
+
+	w.Header().Set("Content-Type", metricsContentType)
+	m := &metricsWriter{w: w}
+
+	m.family("taskmaster_instance_state", "gauge", "Current state of each instance: 1 for its state, 0 for the others.")
+	for _, instance := range metrics.Instances {
+		for _, state := range process.States() {
+			value := 0.0
+			if instance.State == state {
+				value = 1
+			}
+			m.sample("taskmaster_instance_state", value,
+				"program", instance.Program, "instance", instance.Name, "state", state.String())
+		}
+	}
+
+	m.family("taskmaster_instance_start_time_seconds", "gauge", "Unix time at which each instance was last started.")
+	for _, instance := range metrics.Instances {
+		if !instance.StartTime.IsZero() {
+			m.sample("taskmaster_instance_start_time_seconds", unixSeconds(instance.StartTime),
+				"program", instance.Program, "instance", instance.Name)
+		}
+	}
+
+	m.family("taskmaster_instance_uptime_seconds", "gauge", "Seconds each instance has been RUNNING (0 otherwise).")
+	for _, instance := range metrics.Instances {
+		m.sample("taskmaster_instance_uptime_seconds", instance.Uptime,
+			"program", instance.Program, "instance", instance.Name)
+	}
+
+	m.family("taskmaster_instance_restarts_total", "counter", "Automatic restarts of each instance by reason (exit or the exceeded limit).")
+	for _, restart := range metrics.Restarts {
+		m.sample("taskmaster_instance_restarts_total", float64(restart.Count),
+			"program", restart.Program, "instance", restart.Instance, "reason", restart.Reason)
+	}
+
+	m.family("taskmaster_program_exits_total", "counter", "Process exits of each program by exit code (128+N when killed by signal N).")
+	for _, exit := range metrics.Exits {
+		m.sample("taskmaster_program_exits_total", float64(exit.Count),
+			"program", exit.Program, "exit_code", strconv.Itoa(exit.ExitCode))
+	}
+
+	m.family("taskmaster_programs", "gauge", "Programs in the current configuration.")
+	m.sample("taskmaster_programs", float64(metrics.Programs))
+
+	m.family("taskmaster_start_time_seconds", "gauge", "Unix time at which the supervisor started.")
+	m.sample("taskmaster_start_time_seconds", unixSeconds(metrics.StartTime))
+
+	m.family("taskmaster_uptime_seconds", "gauge", "Seconds since the supervisor started.")
+	m.sample("taskmaster_uptime_seconds", now.Sub(metrics.StartTime).Seconds())
+
+	m.family("taskmaster_websocket_clients", "gauge", "Connected WebSocket clients.")
+	m.sample("taskmaster_websocket_clients", float64(s.hub.GetClientCount()))
+
+	m.family("taskmaster_websocket_dropped_messages_total", "counter", "WebSocket messages dropped because a buffer was full.")
+	m.sample("taskmaster_websocket_dropped_messages_total", float64(s.hub.DroppedMessages()))
+
+	m.family("taskmaster_goroutines", "gauge", "Goroutines in the supervisor.")
+	m.sample("taskmaster_goroutines", float64(runtime.NumGoroutine()))
+}
+
+// metricsWriter writes metric families and samples in the text format.
+type metricsWriter struct {
+	w io.Writer
+}
+
+func (m *metricsWriter) family(name, kind, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value; labels are name/value pairs.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(m.w, "%s %s\n", b.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labelEscaper escapes label values as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"bufio"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// metricLine matches a sample of the text exposition format:
+// name{label="value",...} value
+var metricLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)` +
+	`(?:\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*")*\})? (\S+)$`)
+
+func TestMetricsExpositionFormat(t *testing.T) {
+	_, srv := newTestServer(t, "programs:\n  web:\n    cmd: \"sleep 100\"\n    autostart: false\n    starttime: 60\n", Options{})
+	var program struct{}
+	if resp := request(t, "POST", srv.URL+"/api/programs/web/start", "", &program); resp.StatusCode != http.StatusOK {
+		t.Fatalf("start web: status %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != metricsContentType {
+		t.Errorf("Content-Type = %q, want %q", got, metricsContentType)
+	}
+
+	// Every sample belongs to the family declared right before it, with its
+	// HELP and then its TYPE, and no family is declared twice
+	samples := make(map[string]string)
+	declared := make(map[string]bool)
+	var help, family string
+	scanner := bufio.NewScanner(resp.Body)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+			fields := strings.SplitN(line, " ", 4)
+			if len(fields) < 4 || fields[3] == "" {
+				t.Errorf("line %d: HELP without text: %q", n, line)
+				continue
+			}
+			help, family = fields[2], ""
+			if declared[help] {
+				t.Errorf("line %d: family %s declared twice", n, help)
+			}
+			declared[help] = true
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[2] != help {
+				t.Errorf("line %d: TYPE %q does not follow the HELP of its family", n, line)
+				continue
+			}
+			switch fields[3] {
+			case "counter":
+				if !strings.HasSuffix(fields[2], "_total") {
+					t.Errorf("line %d: counter %s does not end in _total", n, fields[2])
+				}
+			case "gauge":
+			default:
+				t.Errorf("line %d: unexpected type %s", n, fields[3])
+			}
+			family = fields[2]
+		default:
+			match := metricLine.FindStringSubmatch(line)
+			if match == nil {
+				t.Errorf("line %d: malformed sample %q", n, line)
+				continue
+			}
+			if match[1] != family {
+				t.Errorf("line %d: sample of %s outside its family (in %q)", n, match[1], family)
+			}
+			if _, err := strconv.ParseFloat(match[2], 64); err != nil {
+				t.Errorf("line %d: invalid value %q", n, match[2])
+			}
+			samples[strings.TrimSuffix(line, " "+match[2])] = match[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		`taskmaster_programs`: "1",
+		`taskmaster_instance_state{program="web",instance="web_0",state="STARTING"}`: "1",
+		`taskmaster_instance_state{program="web",instance="web_0",state="RUNNING"}`:  "0",
+		`taskmaster_instance_uptime_seconds{program="web",instance="web_0"}`:         "0",
+		`taskmaster_websocket_clients`:                                               "0",
+	}
+	for sample, value := range want {
+		if got, exists := samples[sample]; !exists || got != value {
+			t.Errorf("%s = %q, want %q", sample, got, value)
+		}
+	}
+	for _, name := range []string{"taskmaster_instance_restarts_total", "taskmaster_program_exits_total", "taskmaster_goroutines"} {
+		if !declared[name] {
+			t.Errorf("family %s is missing", name)
+		}
+	}
+}
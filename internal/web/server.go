@@ -1,42 +1,76 @@
 package web
 
 import (
-	"fmt"
+	"net"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"taskmaster/internal/logger"
 	"taskmaster/internal/process"
 )
 
 type Server struct {
-	hub     *Hub
-	manager *process.Manager
-	logger  *logger.Logger
-	port    int
+	hub        *Hub
+	manager    *process.Manager
+	logger     *logger.Logger
+	port       int
+	configFile string
+	options    Options
 }
 
 func NewServer(port int, manager *process.Manager, logger *logger.Logger) *Server {
-	hub := NewHub(logger)
-	return &Server{
-		hub:     hub,
+	s := &Server{
 		manager: manager,
 		logger:  logger,
 		port:    port,
 	}
+	s.hub = NewHub(logger, s.originAllowed)
+	s.hub.execute = s.executeCommand
+	return s
+}
+
+// SetOptions sets the bind address, TLS and authentication settings; it must
+// be called before Start.
+func (s *Server) SetOptions(options Options) {
+	s.options = options
+}
+
+// SetConfigFile sets the configuration file used by POST /api/reload.
+func (s *Server) SetConfigFile(configFile string) {
+	s.configFile = configFile
 }
 
 func (s *Server) Start() error {
 	go s.hub.Run()
 
-	http.HandleFunc("/", s.serveHome)
-	http.HandleFunc("/ws", s.hub.ServeWS)
-	http.HandleFunc("/api/status", s.handleStatus)
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
+	bind := s.options.BindAddress
+	if bind == "" {
+		bind = DefaultBindAddress
+	}
+	addr := net.JoinHostPort(bind, strconv.Itoa(s.port))
+	if !isLoopback(bind) && !s.options.authEnabled() {
+		s.logger.Warn("⚠️  Web server on %s accepts requests from the network without authentication", addr)
+	}
+
+	server := &http.Server{Addr: addr, Handler: s.handler()}
+	if s.options.tlsEnabled() {
+		s.logger.Info("Starting web server on https://%s", addr)
+		return server.ListenAndServeTLS(s.options.TLSCertFile, s.options.TLSKeyFile)
+	}
+	s.logger.Info("Starting web server on http://%s", addr)
+	return server.ListenAndServe()
+}
 
-	addr := fmt.Sprintf(":%d", s.port)
-	s.logger.Info("Starting web server on %s", addr)
-	
-	return http.ListenAndServe(addr, nil)
+// handler routes every endpoint behind the authentication and origin checks.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveHome)
+	mux.HandleFunc("/ws", s.hub.ServeWS)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/metrics", allow("GET", s.handleMetrics))
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
+	s.registerAPI(mux)
+	return s.secure(mux)
 }
 
 func (s *Server) serveHome(w http.ResponseWriter, r *http.Request) {
@@ -59,12 +93,10 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	
-	status := s.manager.GetStatus()
-	s.hub.BroadcastStatus(status)
-	
-	w.Write([]byte(`{"status": "ok"}`))
+	// The dashboard also receives the status over the WebSocket
+	s.hub.BroadcastStatus(s.manager.GetStatus())
+
+	writeJSON(w, http.StatusOK, s.manager.Snapshot())
 }
 
 func (s *Server) GetHub() *Hub {
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"taskmaster/internal/logger"
+	"taskmaster/internal/process"
 )
 
 type Hub struct {
@@ -18,6 +21,10 @@ type Hub struct {
 	unregister chan *Client
 	mutex      sync.RWMutex
 	logger     *logger.Logger
+	upgrader   websocket.Upgrader
+	replies    chan reply
+	dropped    atomic.Uint64                          // messages lost because a channel was full
+	execute    func(msg CommandMessage) CommandResult // runs dashboard commands
 }
 
 type Client struct {
@@ -33,24 +40,27 @@ type Message struct {
 }
 
 type LogMessage struct {
-	Level   string `json:"level"`
-	Message string `json:"message"`
-	Program string `json:"program,omitempty"`
+	Level    string `json:"level"`
+	Message  string `json:"message"`
+	Program  string `json:"program,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Stream   string `json:"stream,omitempty"`
+	Event    string `json:"event,omitempty"`
+	PID      int    `json:"pid,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
 }
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
-func NewHub(logger *logger.Logger) *Hub {
+// NewHub creates a hub whose WebSocket upgrades accept only the origins that
+// checkOrigin allows.
+func NewHub(logger *logger.Logger, checkOrigin func(r *http.Request) bool) *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 1000), // Buffered channel to avoid blocking
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		logger:     logger,
+		upgrader:   websocket.Upgrader{CheckOrigin: checkOrigin},
+		replies:    make(chan reply, 256),
 	}
 }
 
@@ -75,13 +85,28 @@ func (h *Hub) Run() {
 			}
 
 		case message := <-h.broadcast:
-			h.mutex.RLock()
+			// Slow clients are disconnected, which modifies the map
+			h.mutex.Lock()
 			for client := range h.clients {
 				select {
 				case client.send <- message:
 				default:
 					close(client.send)
 					delete(h.clients, client)
+					h.dropped.Add(1)
+				}
+			}
+			h.mutex.Unlock()
+
+		case r := <-h.replies:
+			h.mutex.RLock()
+			if _, ok := h.clients[r.client]; ok {
+				select {
+				case r.client.send <- r.data:
+				default:
+					// Don't use logger here to avoid infinite loop
+					log.Printf("Client send buffer full, dropping command result")
+					h.dropped.Add(1)
 				}
 			}
 			h.mutex.RUnlock()
@@ -89,16 +114,25 @@ func (h *Hub) Run() {
 	}
 }
 
-func (h *Hub) BroadcastLog(level, message, program string) {
+// BroadcastLog sends a supervisor log record with its structured fields.
+// Records without a program are attributed to taskmaster itself.
+func (h *Hub) BroadcastLog(record logger.Record) {
 	logMsg := LogMessage{
-		Level:   level,
-		Message: message,
-		Program: program,
+		Level:    record.Level,
+		Message:  record.Message,
+		Program:  record.Program,
+		Instance: record.Instance,
+		Event:    record.Event,
+		PID:      record.PID,
+		ExitCode: record.ExitCode,
+	}
+	if logMsg.Program == "" {
+		logMsg.Program = "taskmaster"
 	}
 
 	msg := Message{
 		Type:      "log",
-		Timestamp: time.Now(),
+		Timestamp: record.Time,
 		Data:      logMsg,
 	}
 
@@ -114,6 +148,38 @@ func (h *Hub) BroadcastLog(level, message, program string) {
 	default:
 		// Don't use logger here to avoid infinite loop
 		log.Printf("Broadcast channel full, dropping message")
+		h.dropped.Add(1)
+	}
+}
+
+// BroadcastOutput sends a line captured from a program's stdout or stderr as a
+// log message whose level is the stream name (STDOUT/STDERR).
+func (h *Hub) BroadcastOutput(line process.OutputLine) {
+	msg := Message{
+		Type:      "log",
+		Timestamp: line.Time,
+		Data: LogMessage{
+			Level:    strings.ToUpper(line.Stream),
+			Message:  line.Text,
+			Program:  line.Program,
+			Instance: line.Instance,
+			Stream:   line.Stream,
+		},
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		// Don't use logger here to avoid infinite loop
+		log.Printf("Failed to marshal output message: %v", err)
+		return
+	}
+
+	select {
+	case h.broadcast <- data:
+	default:
+		// Don't use logger here to avoid infinite loop
+		log.Printf("Broadcast channel full, dropping message")
+		h.dropped.Add(1)
 	}
 }
 
@@ -136,11 +202,12 @@ func (h *Hub) BroadcastStatus(status interface{}) {
 	default:
 		// Don't use logger here to avoid infinite loop
 		log.Printf("Broadcast channel full, dropping message")
+		h.dropped.Add(1)
 	}
 }
 
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		h.logger.Error("WebSocket upgrade failed: %v", err)
 		return
@@ -164,7 +231,7 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(512)
+	c.conn.SetReadLimit(maxCommandSize)
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -172,13 +239,14 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
 			break
 		}
+		c.handleCommand(message)
 	}
 }
 
@@ -225,6 +293,12 @@ func (c *Client) writePump() {
 	}
 }
 
+// DroppedMessages returns how many messages were lost because the broadcast
+// channel or a client's send buffer was full.
+func (h *Hub) DroppedMessages() uint64 {
+	return h.dropped.Load()
+}
+
 func (h *Hub) GetClientCount() int {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
@@ -38,6 +38,16 @@ func SendSignal(process *os.Process, signalName string) error {
 	return process.Signal(sig)
 }
 
+// SendGroupSignal envía una señal a todo el grupo de procesos que lidera pid
+func SendGroupSignal(pid int, signalName string) error {
+	sig, err := GetSignal(signalName)
+	if err != nil {
+		return err
+	}
+
+	return syscall.Kill(-pid, sig.(syscall.Signal))
+}
+
 // GracefulStop intenta parar un proceso gracefully y luego fuerza kill
 func GracefulStop(process *os.Process, stopSignal string, timeout time.Duration) error {
 	// Enviar señal de parada graceful